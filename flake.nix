@@ -12,7 +12,11 @@
         version = "1.0";
         src = ./.;
         vendorHash = "sha256-nb6HFTfngEMF2n0bZj+Lz/U6rVHd87kvgu07QExPt8g=";
-        ldflags = [ "-s" "-w" ];
+        ldflags = [
+          "-s" "-w"
+          "-X github.com/0xkowalskidev/gameserverquery/query.Version=v1.0"
+          "-X github.com/0xkowalskidev/gameserverquery/query.Commit=${self.rev or "dirty"}"
+        ];
       };
     };
 }
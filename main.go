@@ -34,6 +34,8 @@ func main() {
 		scanCmd()
 	case "list":
 		listGames()
+	case "version":
+		printVersion()
 	default:
 		queryCmd()
 	}
@@ -42,10 +44,12 @@ func main() {
 func queryCmd() {
 	var (
 		timeout = flag.Duration("timeout", 5*time.Second, "Query timeout")
-		format  = flag.String("format", "text", "Output format (text, json)")
+		format  = flag.String("format", "text", "Output format (text, json, json-flat, influx)")
 		players = flag.Bool("players", false, "Include player list")
 		game    = flag.String("game", "", "Game type (auto-detect if not specified)")
 		debug   = flag.Bool("debug", false, "Enable debug logging")
+		retries = flag.Int("retries", 0, "Retry transient failures this many times")
+		quiet   = flag.Bool("quiet", false, "Print only results to stdout and errors to stderr")
 	)
 	flag.Parse()
 
@@ -67,6 +71,9 @@ func queryCmd() {
 	if *debug {
 		opts = append(opts, query.WithDebug())
 	}
+	if *retries > 0 {
+		opts = append(opts, query.WithRetries(*retries))
+	}
 
 	var info *protocol.ServerInfo
 	var err error
@@ -83,7 +90,7 @@ func queryCmd() {
 		os.Exit(1)
 	}
 
-	if err := outputResult(info, *format); err != nil {
+	if err := outputResult(info, *format, *quiet); err != nil {
 		fmt.Fprintf(os.Stderr, "Output error: %v\n", err)
 		os.Exit(1)
 	}
@@ -92,14 +99,18 @@ func queryCmd() {
 func scanCmd() {
 	var (
 		timeout     = flag.Duration("timeout", 5*time.Second, "Query timeout per server")
-		format      = flag.String("format", "text", "Output format (text, json)")
+		format      = flag.String("format", "text", "Output format (text, json, json-flat, influx)")
 		players     = flag.Bool("players", false, "Include player list")
 		portStart   = flag.Int("port-start", 0, "Start of port range to scan")
 		portEnd     = flag.Int("port-end", 0, "End of port range to scan")
 		ports       = flag.String("ports", "", "Comma-separated list of ports to scan")
-		concurrency = flag.Int("concurrency", 10, "Maximum concurrent queries")
+		preset      = flag.String("preset", "", "Named port preset to scan ("+strings.Join(query.PortPresetNames(), ", ")+")")
+		concurrency = flag.Int("concurrency", 0, "Maximum concurrent queries (0 = auto)")
 		noProgress  = flag.Bool("no-progress", false, "Disable progress indicator")
 		debug       = flag.Bool("debug", false, "Enable debug logging")
+		unknown     = flag.Bool("report-unknown", false, "Report TCP listeners no protocol recognized")
+		offline     = flag.Bool("include-offline", false, "Report given ports that didn't answer as offline")
+		quiet       = flag.Bool("quiet", false, "Print only results to stdout and errors to stderr")
 	)
 	flag.Parse()
 
@@ -126,6 +137,14 @@ func scanCmd() {
 		opts = append(opts, query.WithDebug())
 	}
 
+	if *unknown {
+		opts = append(opts, query.WithReportUnknownListeners())
+	}
+
+	if *offline {
+		opts = append(opts, query.WithIncludeOffline())
+	}
+
 	// Handle port options
 	if *ports != "" {
 		// Parse custom ports
@@ -139,14 +158,21 @@ func scanCmd() {
 		if len(portList) > 0 {
 			opts = append(opts, query.WithPorts(portList))
 		}
+	} else if *preset != "" {
+		// Use named port preset
+		if _, exists := query.PortPreset(*preset); !exists {
+			fmt.Fprintf(os.Stderr, "Unknown preset: %s (available: %s)\n", *preset, strings.Join(query.PortPresetNames(), ", "))
+			os.Exit(1)
+		}
+		opts = append(opts, query.WithPortPreset(*preset))
 	} else if *portStart > 0 && *portEnd >= *portStart {
 		// Use port range
 		opts = append(opts, query.WithPortRange(*portStart, *portEnd))
 	}
 	// Otherwise, scan all default ports (default behavior)
 
-	// Use progress indicator unless disabled or JSON format
-	showProgress := !*noProgress && *format != "json"
+	// Use progress indicator unless disabled, quiet or a machine-readable format
+	showProgress := !*noProgress && !*quiet && !strings.HasPrefix(*format, "json") && *format != "influx"
 
 	var servers []*protocol.ServerInfo
 	var err error
@@ -209,11 +235,14 @@ func scanCmd() {
 	}
 
 	if len(servers) == 0 {
-		fmt.Println("No game servers found")
+		// An empty influx result is no points rather than a line the database would reject
+		if !*quiet && *format != "influx" {
+			fmt.Println("No game servers found")
+		}
 		return
 	}
 
-	if err := outputScanResults(servers, *format); err != nil {
+	if err := outputScanResults(servers, *format, *quiet); err != nil {
 		fmt.Fprintf(os.Stderr, "Output error: %v\n", err)
 		os.Exit(1)
 	}
@@ -226,22 +255,28 @@ Usage:
   gameserverquery [options] <address[:port]>    # Query a single server
   gameserverquery scan [options] <address>      # Scan for multiple servers
   gameserverquery list                          # List supported games
+  gameserverquery version                       # Show version, commit and Go version
 
 Common Options:
   -timeout duration    Query timeout (default 5s)
-  -format string       Output format: text, json (default "text")
+  -format string       Output format: text, json, json-flat, influx (default "text")
   -players             Include player list
   -debug               Enable debug logging
+  -quiet               Print only results to stdout and errors to stderr
 
 Query Options:
   -game string         Game type (auto-detect if not specified)
+  -retries int         Retry transient failures this many times
 
 Scan Options:
   -port-start int      Start of port range to scan
   -port-end int        End of port range to scan
   -ports string        Comma-separated list of ports to scan
-  -concurrency int     Maximum concurrent queries (default 10)
+  -preset string       Named port preset to scan (steam, minecraft, survival)
+  -concurrency int     Maximum concurrent queries (default auto)
   -no-progress         Disable progress indicator
+  -report-unknown      Report TCP listeners no protocol recognized
+  -include-offline     Report given ports that didn't answer as offline
 
 Examples:
   gameserverquery play.hypixel.net                        # Query gameserver (auto-detect)
@@ -249,41 +284,59 @@ Examples:
   gameserverquery -game minecraft play.hypixel.net:25565  # Query gameserver with port and/or game, faster
   gameserverquery -game ark-survival-evolved server.com   # Uses query port 27015 automatically
   gameserverquery scan 127.0.0.1                          # Scan address for gameservers
+  gameserverquery scan -preset steam 127.0.0.1            # Scan the Steam port cluster
 `)
 }
 
-func listGames() {
-	games := query.SupportedGames()
-	sort.Strings(games)
+func printVersion() {
+	info := query.ReadBuildInfo()
+	fmt.Printf("gameserverquery %s\n", info.Version)
+	if info.Commit != "" {
+		fmt.Printf("commit: %s\n", info.Commit)
+	}
+	fmt.Printf("go: %s\n", info.GoVersion)
+}
 
+func listGames() {
 	fmt.Println("Supported games:")
-	for _, game := range games {
-		gamePort := query.DefaultPort(game)
-		queryPort := query.DefaultQueryPort(game)
-		if gamePort == queryPort {
-			fmt.Printf("  %-15s (port: %d)\n", game, gamePort)
-		} else {
-			fmt.Printf("  %-15s (game: %d, query: %d)\n", game, gamePort, queryPort)
+	for _, proto := range protocol.ListProtocols() {
+		fmt.Printf("\n  %s\n", proto.Name)
+		for _, game := range proto.Games {
+			if game.GamePort == game.QueryPort {
+				fmt.Printf("    %-24s (port: %d)\n", game.Name, game.GamePort)
+			} else {
+				fmt.Printf("    %-24s (game: %d, query: %d)\n", game.Name, game.GamePort, game.QueryPort)
+			}
 		}
 	}
 }
 
-func outputResult(info *protocol.ServerInfo, format string) error {
+func outputResult(info *protocol.ServerInfo, format string, quiet bool) error {
 	switch format {
 	case "json":
 		encoder := json.NewEncoder(os.Stdout)
 		encoder.SetIndent("", "  ")
 		return encoder.Encode(info)
+	case "json-flat":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info.Flat())
+	case "influx":
+		return query.FormatInfluxLine(os.Stdout, []*protocol.ServerInfo{info})
 	case "text":
-		return outputText(info)
+		return outputText(info, quiet)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
-func outputText(info *protocol.ServerInfo) error {
+// outputText prints the server; quiet leaves out the notice for an offline server, which
+// has no result to print
+func outputText(info *protocol.ServerInfo, quiet bool) error {
 	if !info.Online {
-		fmt.Printf("Server %s:%d is offline\n", info.Address, info.Port)
+		if !quiet {
+			fmt.Printf("Server %s:%d is offline\n", info.Address, info.Port)
+		}
 		return nil
 	}
 
@@ -295,11 +348,15 @@ func outputText(info *protocol.ServerInfo) error {
 	}
 	fmt.Printf("Address: %s:%d\n", info.Address, info.Port)
 	fmt.Printf("Query Port: %d\n", info.QueryPort)
+	fmt.Printf("Connect: %s\n", info.ConnectString())
 	fmt.Printf("Players: %d/%d\n", info.Players.Current, info.Players.Max)
 	fmt.Printf("Ping: %d\n", info.Ping)
 
 	// Optional fields
 	printIfNotEmpty("Map", info.Map)
+	printIfNotEmpty("Region", info.Region)
+	printIfNotEmpty("Language", info.Language)
+	printIfNotEmpty("Plugins", strings.Join(info.Plugins, ", "))
 	fmt.Printf("Online: %t\n", info.Online)
 
 	// Extra information
@@ -320,43 +377,72 @@ func printIfNotEmpty(label, value string) {
 func printExtra(extra map[string]string) {
 	if len(extra) > 0 {
 		fmt.Println("\nExtra Information:")
-		for key, value := range extra {
-			fmt.Printf("  %s: %s\n", key, value)
+		for _, key := range sortedKeys(extra) {
+			fmt.Printf("  %s: %s\n", key, extra[key])
 		}
 	}
 }
 
+// sortedKeys returns the keys of m in order, so text output is the same on every run
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func printPlayers(players []protocol.Player) {
 	if len(players) > 0 {
 		fmt.Println("\nPlayers:")
 		for _, player := range players {
 			parts := []string{player.Name}
+			if player.Team != "" {
+				parts = append(parts, fmt.Sprintf("[%s]", player.Team))
+			}
 			if player.Score > 0 {
 				parts = append(parts, fmt.Sprintf("Score: %d", player.Score))
 			}
 			if player.Duration > 0 {
 				parts = append(parts, fmt.Sprintf("Time: %v", player.Duration))
 			}
+			if player.Ping > 0 {
+				parts = append(parts, fmt.Sprintf("Ping: %dms", player.Ping))
+			}
 			fmt.Printf("  %s\n", strings.Join(parts, " "))
 		}
 	}
 }
 
-func outputScanResults(servers []*protocol.ServerInfo, format string) error {
+func outputScanResults(servers []*protocol.ServerInfo, format string, quiet bool) error {
 	switch format {
 	case "json":
 		encoder := json.NewEncoder(os.Stdout)
 		encoder.SetIndent("", "  ")
 		return encoder.Encode(servers)
+	case "json-flat":
+		flat := make([]protocol.FlatServerInfo, len(servers))
+		for i, info := range servers {
+			flat[i] = info.Flat()
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(flat)
+	case "influx":
+		return query.FormatInfluxLine(os.Stdout, servers)
 	case "text":
-		return outputScanText(servers)
+		return outputScanText(servers, quiet)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
-func outputScanText(servers []*protocol.ServerInfo) error {
-	fmt.Printf("Found %d game server(s)\n\n", len(servers))
+// outputScanText prints each server; quiet leaves out the "Found N" summary line
+func outputScanText(servers []*protocol.ServerInfo, quiet bool) error {
+	if !quiet {
+		fmt.Printf("Found %d game server(s)\n\n", len(servers))
+	}
 
 	for i, info := range servers {
 		if i > 0 {
@@ -368,6 +454,9 @@ func outputScanText(servers []*protocol.ServerInfo) error {
 			fmt.Printf("  Name: %s\n", info.Name)
 		}
 		fmt.Printf("  Game: %s\n", info.Game)
+		if !info.Online {
+			fmt.Println("  Online: false")
+		}
 		fmt.Printf("  Address: %s:%d\n", info.Address, info.Port)
 		fmt.Printf("  Query Port: %d\n", info.QueryPort)
 		fmt.Printf("  Players: %d/%d\n", info.Players.Current, info.Players.Max)
@@ -377,9 +466,21 @@ func outputScanText(servers []*protocol.ServerInfo) error {
 		if info.Map != "" {
 			fmt.Printf("  Map: %s\n", info.Map)
 		}
+		if info.Region != "" {
+			fmt.Printf("  Region: %s\n", info.Region)
+		}
+		if info.Language != "" {
+			fmt.Printf("  Language: %s\n", info.Language)
+		}
 		if info.Ping > 0 {
 			fmt.Printf("  Ping: %dms\n", info.Ping)
 		}
+		if len(info.Extra) > 0 {
+			fmt.Printf("  Extra:\n")
+			for _, key := range sortedKeys(info.Extra) {
+				fmt.Printf("    %s: %s\n", key, info.Extra[key])
+			}
+		}
 
 		// Show player list if available
 		if len(info.Players.List) > 0 {
@@ -392,6 +493,9 @@ func outputScanText(servers []*protocol.ServerInfo) error {
 				if player.Duration > 0 {
 					fmt.Printf(" (Time: %v)", player.Duration)
 				}
+				if player.Ping > 0 {
+					fmt.Printf(" (Ping: %dms)", player.Ping)
+				}
 				fmt.Println()
 			}
 		}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureStdout returns everything fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+	w.Close()
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	return string(output)
+}
+
+func TestTextOutput_ExtraInStableOrder(t *testing.T) {
+	info := &protocol.ServerInfo{
+		Name:   "Server",
+		Game:   "a2s",
+		Online: true,
+		Extra:  map[string]string{"os": "linux", "app_id": "440", "server_type": "dedicated", "game": "tf", "ping_min": "3"},
+	}
+
+	text := captureStdout(t, func() { outputText(info, false) })
+	assert.Contains(t, text, "  app_id: 440\n  game: tf\n  os: linux\n  ping_min: 3\n  server_type: dedicated\n")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, text, captureStdout(t, func() { outputText(info, false) }))
+	}
+
+	scan := captureStdout(t, func() { outputScanText([]*protocol.ServerInfo{info}, false) })
+	assert.Contains(t, scan, "    app_id: 440\n    game: tf\n    os: linux\n    ping_min: 3\n    server_type: dedicated\n")
+}
+
+func TestScanText_Quiet(t *testing.T) {
+	servers := []*protocol.ServerInfo{{Name: "Server", Game: "a2s", Online: true}}
+
+	assert.Contains(t, captureStdout(t, func() { outputScanText(servers, false) }), "Found 1 game server(s)")
+
+	quiet := captureStdout(t, func() { outputScanText(servers, true) })
+	assert.NotContains(t, quiet, "Found")
+	assert.True(t, strings.HasPrefix(quiet, "Server #1\n"))
+}
+
+func TestText_Quiet(t *testing.T) {
+	offline := &protocol.ServerInfo{Address: "127.0.0.1", Port: 27015}
+
+	assert.Equal(t, "Server 127.0.0.1:27015 is offline\n", captureStdout(t, func() { outputText(offline, false) }))
+	assert.Empty(t, captureStdout(t, func() { outputText(offline, true) }))
+
+	// Results are printed either way
+	online := &protocol.ServerInfo{Name: "Server", Game: "a2s", Online: true}
+	assert.Equal(t, captureStdout(t, func() { outputText(online, false) }), captureStdout(t, func() { outputText(online, true) }))
+}
@@ -6,12 +6,36 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // A2SProtocol implements the A2S_INFO protocol
 type A2SProtocol struct{}
 
+// a2sServerTypes maps the lowercased server type byte to a readable name
+var a2sServerTypes = map[uint8]string{
+	'd': "dedicated",
+	'l': "listen",
+	'p': "sourcetv",
+}
+
+// a2sEnvironments maps the lowercased environment byte to an operating system
+var a2sEnvironments = map[uint8]string{
+	'l': "linux",
+	'w': "windows",
+	'm': "mac",
+	'o': "mac",
+}
+
+// subQueryBudget is the share of the query timeout each player or rules sub-query may use
+const subQueryBudget = 0.5
+
+// a2sNextMapRules lists the rules that carry the next map, in order of preference:
+// SourceMod and Source engine cvars first, then the Quake-style cvars some mods mirror
+var a2sNextMapRules = []string{"sm_nextmap", "mp_nextmap", "nextlevel", "g_nextmap", "nextmap"}
+
 func init() {
 	registry.Register(&A2SProtocol{})
 }
@@ -20,6 +44,10 @@ func (s *A2SProtocol) Name() string {
 	return "a2s"
 }
 
+func (s *A2SProtocol) Network() string {
+	return "udp"
+}
+
 func (s *A2SProtocol) DefaultPort() int {
 	return 27015
 }
@@ -41,17 +69,26 @@ func (s *A2SProtocol) Games() []GameConfig {
 		{Name: "half-life", GamePort: 27015, QueryPort: 27015},
 		{Name: "insurgency", GamePort: 27015, QueryPort: 27015},
 		{Name: "day-of-defeat", GamePort: 27015, QueryPort: 27015},
-		{Name: "project-zomboid", GamePort: 16261, QueryPort: 16261},
+		{Name: "project-zomboid", GamePort: 16261, QueryPort: 16261}, // Also needs 16262 for direct connections
 		{Name: "satisfactory", GamePort: 7777, QueryPort: 15777},
 		{Name: "7-days-to-die", GamePort: 26900, QueryPort: 26900},
 		{Name: "arma-3", GamePort: 2302, QueryPort: 2303},
 		{Name: "dayz", GamePort: 2302, QueryPort: 27016},
 		{Name: "battalion-1944", GamePort: 7777, QueryPort: 7777},
+		{Name: "space-engineers", GamePort: 27016, QueryPort: 27016},
 
 		// Games with non standard ports
 		{Name: "rust", GamePort: 28015, QueryPort: 28015},
 		{Name: "valheim", GamePort: 2456, QueryPort: 2457},
 		{Name: "ark-survival-evolved", GamePort: 7777, QueryPort: 27015},
+		{Name: "squad", GamePort: 7787, QueryPort: 27165},
+		{Name: "post-scriptum", GamePort: 7787, QueryPort: 27165},
+		{Name: "mordhau", GamePort: 7777, QueryPort: 27015},
+		{Name: "insurgency-sandstorm", GamePort: 27102, QueryPort: 27131},
+		{Name: "wreckfest", GamePort: 33540, QueryPort: 27015},
+		{Name: "red-orchestra-2", GamePort: 7777, QueryPort: 27015},
+		{Name: "rising-storm-2", GamePort: 7777, QueryPort: 27015},
+		{Name: "barotrauma", GamePort: 27015, QueryPort: 27016}, // Direct-IP servers only; relay-only servers have no address to query
 	}
 }
 
@@ -75,7 +112,7 @@ func (s *A2SProtocol) DetectGame(info *ServerInfo) string {
 
 func (s *A2SProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
 	if opts.Debug {
-		debugLogf("A2S", "Starting query for %s", addr)
+		debugLogf(opts, "A2S", "Starting query for %s", addr)
 	}
 
 	conn, err := setupConnection(ctx, "udp", addr, opts)
@@ -84,12 +121,27 @@ func (s *A2SProtocol) Query(ctx context.Context, addr string, opts *Options) (*S
 	}
 	defer conn.Close()
 
+	// In parallel mode the player and rules queries run on their own sockets alongside the info exchange
+	var parallelPlayers, parallelRules <-chan subQueryResult
+	if opts.Players && opts.ParallelQueries {
+		parallelPlayers = s.startSubQuery(ctx, addr, opts, s.subQueryDeadline(ctx, opts), "player list", func(conn net.Conn) subQueryResult {
+			players, err := s.queryPlayers(conn, a2sUnknownDialect, 0)
+			return subQueryResult{players: players, err: err}
+		})
+	}
+	if opts.Rules && opts.ParallelQueries {
+		parallelRules = s.startSubQuery(ctx, addr, opts, s.subQueryDeadline(ctx, opts), "rules", func(conn net.Conn) subQueryResult {
+			rules, err := s.queryRules(conn, a2sUnknownDialect, 0)
+			return subQueryResult{rules: rules, err: err}
+		})
+	}
+
 	// Build A2S_INFO request
 	request := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x54}
 	request = append(request, []byte("Source Engine Query\x00")...)
 
 	if opts.Debug {
-		debugLogf("A2S", "Sending A2S_INFO request (%d bytes)", len(request))
+		debugLogf(opts, "A2S", "Sending A2S_INFO request (%d bytes)", len(request))
 	}
 
 	// Measure ping from request send to response receive
@@ -98,73 +150,84 @@ func (s *A2SProtocol) Query(ctx context.Context, addr string, opts *Options) (*S
 	// Send request
 	if _, err := conn.Write(request); err != nil {
 		if opts.Debug {
-			debugLogf("A2S", "Request write failed: %v", err)
+			debugLogf(opts, "A2S", "Request write failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("write failed: %w", err)
 	}
 
 	// Read response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
+	response, err := s.readResponse(conn)
+	n := len(response)
 	pingDuration := time.Since(pingStart)
 	ping := int(math.Ceil(float64(pingDuration.Nanoseconds()) / 1e6))
 
 	if err != nil {
 		if opts.Debug {
-			debugLogf("A2S", "Response read failed: %v", err)
+			debugLogf(opts, "A2S", "Response read failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("read failed: %w", err)
 	}
 
 	if opts.Debug {
-		debugLogf("A2S", "Received %d bytes response (ping: %dms)", n, ping)
+		debugLogf(opts, "A2S", "Received %d bytes response (ping: %dms)", n, ping)
 	}
 
 	if n < 5 {
 		if opts.Debug {
-			debugLogf("A2S", "Response too short (%d bytes)", n)
+			debugLogf(opts, "A2S", "Response too short (%d bytes)", n)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("response too short")
 	}
 
+	var info *A2SInfo
+	var challenge uint32
+	challengeRequired := false
+
 	// Check for challenge response
 	if response[4] == 0x41 { // Challenge response
 		if opts.Debug {
-			debugLog("A2S", "Received challenge response")
+			debugLog(opts, "A2S", "Received challenge response")
 		}
 		if n < 9 {
 			return &ServerInfo{Online: false}, fmt.Errorf("challenge response too short")
 		}
-		challenge := binary.LittleEndian.Uint32(response[5:9])
+		challenge = binary.LittleEndian.Uint32(response[5:9])
 		if opts.Debug {
-			debugLogf("A2S", "Challenge value: 0x%08x", challenge)
+			debugLogf(opts, "A2S", "Challenge value: 0x%08x", challenge)
+		}
+		challengeRequired = true
+		// Keep the ping from the first request rather than measuring the challenge exchange
+		info, challenge, err = s.queryWithChallenge(conn, challenge)
+		if err != nil {
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Challenge query failed: %v", err)
+			}
+			return &ServerInfo{Online: false}, err
+		}
+	} else {
+		// Check for A2S_INFO response
+		if response[4] != 0x49 {
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Unexpected response type: 0x%02x (expected 0x49)", response[4])
+			}
+			return &ServerInfo{Online: false}, fmt.Errorf("unexpected response type: %02x", response[4])
 		}
-		return s.queryWithChallenge(conn, addr, challenge, getTimeout(opts), ping, opts)
-	}
 
-	// Check for A2S_INFO response
-	if response[4] != 0x49 {
 		if opts.Debug {
-			debugLogf("A2S", "Unexpected response type: 0x%02x (expected 0x49)", response[4])
+			debugLog(opts, "A2S", "Parsing A2S_INFO response")
 		}
-		return &ServerInfo{Online: false}, fmt.Errorf("unexpected response type: %02x", response[4])
-	}
-
-	if opts.Debug {
-		debugLog("A2S", "Parsing A2S_INFO response")
-	}
 
-	// Parse A2S_INFO response
-	info, err := s.parseA2SInfoResponse(response[5:n])
-	if err != nil {
-		if opts.Debug {
-			debugLogf("A2S", "Response parsing failed: %v", err)
+		// Parse A2S_INFO response
+		info, err = s.parseA2SInfoResponse(response[5:n])
+		if err != nil {
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Response parsing failed: %v", err)
+			}
+			return &ServerInfo{Online: false}, fmt.Errorf("parse failed: %w", err)
 		}
-		return &ServerInfo{Online: false}, fmt.Errorf("parse failed: %w", err)
 	}
 
 	result := &ServerInfo{
-		Name:    info.Name,
 		Map:     info.Map,
 		Version: info.Version,
 		Online:  true,
@@ -176,12 +239,40 @@ func (s *A2SProtocol) Query(ctx context.Context, addr string, opts *Options) (*S
 		// Store game description and App ID for central game detector
 		Extra: map[string]string{
 			"game":   info.Game,
-			"app_id": fmt.Sprintf("%d", info.AppID),
+			"app_id": fmt.Sprintf("%d", info.FullAppID()),
+			// Bots are included in the player count
+			"bots":             strconv.Itoa(int(info.Bots)),
+			"protocol_version": strconv.Itoa(int(info.Protocol)),
 		},
 	}
+	setCleanName(result, s.Name(), info.Name)
+
+	// The EDF game port is where players connect, which need not match the query port
+	if info.ExtraDataFlag&0x80 != 0 && info.Port > 0 {
+		result.Extra["game_port"] = strconv.Itoa(int(info.Port))
+	}
+
+	if serverType := a2sServerTypes[info.ServerType|0x20]; serverType != "" {
+		result.Extra["server_type"] = serverType
+	}
+	if os := a2sEnvironments[info.Environment|0x20]; os != "" {
+		result.Extra["os"] = os
+	}
+
+	// Games pack their own data into the keywords (sv_tags), so keep the untouched string for custom parsing
+	if info.Keywords != "" {
+		result.Extra["keywords_raw"] = info.Keywords
+	}
+
+	// A2S has no region or language fields, but some servers advertise them in their keywords
+	result.Region = s.keywordValue(info.Keywords, "region")
+	result.Language = s.keywordValue(info.Keywords, "language")
+	if result.Language == "" {
+		result.Language = s.keywordValue(info.Keywords, "lang")
+	}
 
 	if opts.Debug {
-		debugLogf("A2S", "Parsed server info - Name: '%s', Game: '%s', Map: '%s', Players: %d/%d",
+		debugLogf(opts, "A2S", "Parsed server info - Name: '%s', Game: '%s', Map: '%s', Players: %d/%d",
 			result.Name, info.Game, result.Map, result.Players.Current, result.Players.Max)
 	}
 
@@ -189,152 +280,494 @@ func (s *A2SProtocol) Query(ctx context.Context, addr string, opts *Options) (*S
 	result.Game = s.DetectGame(result)
 
 	if opts.Debug {
-		debugLogf("A2S", "Detected game type: '%s'", result.Game)
+		debugLogf(opts, "A2S", "Detected game type: '%s'", result.Game)
+	}
+
+	if opts.Capabilities {
+		s.recordCapabilities(result, info, challengeRequired)
+	}
+
+	// Take further latency samples with repeat A2S_INFO requests on the same socket
+	if opts.PingSamples > 1 {
+		samples := append([]int{ping}, s.samplePing(conn, challenge, challengeRequired, opts.PingSamples-1, opts)...)
+		applyPingSamples(result, samples)
+	}
+
+	// Sub-queries follow the conventions of the server's generation
+	dialect := a2sDialectFor(info)
+	if opts.Debug && (opts.Players || opts.Rules) {
+		debugLogf(opts, "A2S", "Using %s query conventions", dialect.name)
 	}
 
 	// Query players if requested
 	if opts.Players {
-		if opts.Debug {
-			debugLog("A2S", "Querying player list")
+		var players []Player
+		if parallelPlayers != nil {
+			outcome := <-parallelPlayers
+			players, err = outcome.players, outcome.err
+		} else {
+			playerDeadline := s.subQueryDeadline(ctx, opts)
+			conn.SetDeadline(playerDeadline)
+
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Querying player list (deadline %v)", playerDeadline)
+			}
+			players, err = s.queryPlayers(conn, dialect, challenge)
 		}
-		players, err := s.queryPlayers(conn, addr, getTimeout(opts))
 		if err == nil {
 			result.Players.List = players
 			if opts.Debug {
-				debugLogf("A2S", "Retrieved %d players", len(players))
+				debugLogf(opts, "A2S", "Retrieved %d players", len(players))
 			}
 		} else {
 			if opts.Debug {
-				debugLogf("A2S", "Player query failed: %v", err)
+				debugLogf(opts, "A2S", "Player query failed: %v", err)
 			}
 			result.Players.List = make([]Player, 0)
+			result.Extra["player_list_unavailable"] = "true"
+		}
+	}
+
+	// Query rules if requested
+	if opts.Rules {
+		var rules map[string]string
+		if parallelRules != nil {
+			outcome := <-parallelRules
+			rules, err = outcome.rules, outcome.err
+		} else {
+			rulesDeadline := s.subQueryDeadline(ctx, opts)
+			conn.SetDeadline(rulesDeadline)
+
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Querying rules (deadline %v)", rulesDeadline)
+			}
+			rules, err = s.queryRules(conn, dialect, challenge)
+		}
+		if err == nil {
+			result.Rules = rules
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Retrieved %d rules", len(rules))
+			}
+		} else {
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Rules query failed: %v", err)
+			}
+			result.Extra["rules_unavailable"] = "true"
 		}
 	}
 
+	// Servers advertise the next map in their rules or, failing that, their keywords
+	if nextMap := s.nextMap(result.Rules, info.Keywords); nextMap != "" {
+		result.Extra["next_map"] = nextMap
+	}
+
+	// Document game-specific behaviour that callers would otherwise trip over
+	s.applyGameQuirks(result, addr, opts)
+
 	if opts.Debug {
-		debugLog("A2S", "Query completed successfully")
+		debugLog(opts, "A2S", "Query completed successfully")
 	}
 	return result, nil
 }
 
-func (s *A2SProtocol) queryWithChallenge(conn net.Conn, addr string, challenge uint32, timeout time.Duration, initialPing int, opts *Options) (*ServerInfo, error) {
-	// Build A2S_INFO request with challenge
+// Probe sends an A2S_INFO request and accepts either an info or a challenge response
+func (s *A2SProtocol) Probe(ctx context.Context, addr string, opts *Options) error {
+	conn, err := setupConnection(ctx, "udp", addr, opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
 	request := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x54}
 	request = append(request, []byte("Source Engine Query\x00")...)
-	challengeBytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(challengeBytes, challenge)
-	request = append(request, challengeBytes...)
-
-	// Send request with challenge
 	if _, err := conn.Write(request); err != nil {
-		return &ServerInfo{Online: false}, fmt.Errorf("write challenge failed: %w", err)
+		return fmt.Errorf("write failed: %w", err)
 	}
 
-	// Read response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
+	response, err := s.readResponse(conn)
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if len(response) < 5 || binary.LittleEndian.Uint32(response[:4]) != 0xFFFFFFFF {
+		return fmt.Errorf("invalid response")
+	}
+	if response[4] != 0x49 && response[4] != 0x41 {
+		return fmt.Errorf("unexpected response type: %02x", response[4])
+	}
+	return nil
+}
 
-	// Use the initial ping from the first request rather than measuring challenge exchange
-	ping := initialPing
+// queryWithChallenge repeats A2S_INFO with the server's challenge and returns the parsed
+// info with the challenge that was finally accepted. The server generation is unknown until
+// the info is parsed, so a rotated challenge is answered as tolerantly as Source 2 needs.
+func (s *A2SProtocol) queryWithChallenge(conn net.Conn, challenge uint32) (*A2SInfo, uint32, error) {
+	for round := 0; ; round++ {
+		// Build A2S_INFO request with challenge
+		request := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x54}
+		request = append(request, []byte("Source Engine Query\x00")...)
+		challengeBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(challengeBytes, challenge)
+		request = append(request, challengeBytes...)
 
-	if err != nil {
-		return &ServerInfo{Online: false}, fmt.Errorf("read challenge response failed: %w", err)
+		// Send request with challenge
+		if _, err := conn.Write(request); err != nil {
+			return nil, 0, fmt.Errorf("write challenge failed: %w", err)
+		}
+
+		// Read response
+		response, err := s.readResponse(conn)
+		n := len(response)
+		if err != nil {
+			return nil, 0, fmt.Errorf("read challenge response failed: %w", err)
+		}
+
+		// Answer a rotated challenge with the new value
+		if n >= 9 && response[4] == 0x41 && round+1 < a2sUnknownDialect.challengeRounds {
+			challenge = binary.LittleEndian.Uint32(response[5:9])
+			continue
+		}
+
+		if n < 5 || response[4] != 0x49 {
+			return nil, 0, fmt.Errorf("invalid challenge response")
+		}
+
+		// Parse A2S_INFO response
+		info, err := s.parseA2SInfoResponse(response[5:n])
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse challenge response failed: %w", err)
+		}
+
+		return info, challenge, nil
 	}
+}
+
+// samplePing repeats the A2S_INFO request count times, reusing the known challenge, and
+// returns the round trip of each answered request. Sampling stops at the first failure.
+func (s *A2SProtocol) samplePing(conn net.Conn, challenge uint32, challengeRequired bool, count int, opts *Options) []int {
+	samples := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		request := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x54}
+		request = append(request, []byte("Source Engine Query\x00")...)
+		if challengeRequired {
+			challengeBytes := make([]byte, 4)
+			binary.LittleEndian.PutUint32(challengeBytes, challenge)
+			request = append(request, challengeBytes...)
+		}
+
+		start := time.Now()
+		if _, err := conn.Write(request); err != nil {
+			break
+		}
+		response, err := s.readResponse(conn)
+		if err != nil || len(response) < 9 || (response[4] != 0x49 && response[4] != 0x41) {
+			if opts.Debug {
+				debugLogf(opts, "A2S", "Ping sample %d failed: %v", i+2, err)
+			}
+			break
+		}
+		samples = append(samples, int(math.Ceil(float64(time.Since(start).Nanoseconds())/1e6)))
 
-	if n < 5 || response[4] != 0x49 {
-		return &ServerInfo{Online: false}, fmt.Errorf("invalid challenge response")
+		// A challenge reply still measures the round trip; use the new value from here on
+		if response[4] == 0x41 {
+			challenge = binary.LittleEndian.Uint32(response[5:9])
+			challengeRequired = true
+		}
 	}
+	return samples
+}
 
-	// Parse A2S_INFO response
-	info, err := s.parseA2SInfoResponse(response[5:n])
-	if err != nil {
-		return &ServerInfo{Online: false}, fmt.Errorf("parse challenge response failed: %w", err)
+// recordCapabilities infers which follow-up queries are worth issuing from the info response
+func (s *A2SProtocol) recordCapabilities(result *ServerInfo, info *A2SInfo, challengeRequired bool) {
+	// Sub-queries need a challenge round trip whenever the info query did
+	result.Extra["challenge_required"] = strconv.FormatBool(challengeRequired)
+
+	// An empty server or a SourceTV relay ('p') has no player list worth fetching
+	playersAvailable := info.Players > 0 && info.ServerType|0x20 != 'p'
+	result.Extra["players_available"] = strconv.FormatBool(playersAvailable)
+}
+
+// applyGameQuirks records known per-game deviations from standard A2S behaviour in info.Extra
+func (s *A2SProtocol) applyGameQuirks(info *ServerInfo, addr string, opts *Options) {
+	switch info.Game {
+	case "project-zomboid":
+		// Project Zomboid answers A2S on its game port (16261) but also needs the
+		// next UDP port (16262) open for direct client connections
+		if _, portStr, err := net.SplitHostPort(addr); err == nil {
+			if port, err := strconv.Atoi(portStr); err == nil {
+				info.Extra["secondary_port"] = strconv.Itoa(port + 1)
+			}
+		}
+
+		// Its A2S_PLAYER response is usually empty even with players online
+		if opts.Players && len(info.Players.List) == 0 && info.Players.Current > 0 {
+			info.Extra["player_list_unavailable"] = "true"
+		}
 	}
+}
 
-	result := &ServerInfo{
-		Name:    info.Name,
-		Map:     info.Map,
-		Version: info.Version,
-		Online:  true,
-		Players: PlayerInfo{
-			Current: int(info.Players),
-			Max:     int(info.MaxPlayers),
-		},
-		Ping: ping,
-		// Store game description and App ID for central game detector
-		Extra: map[string]string{
-			"game":   info.Game,
-			"app_id": fmt.Sprintf("%d", info.AppID),
-		},
+// a2sDialect holds the query conventions of a Source server generation, so the differences
+// between them live here rather than in conditionals across the query code
+type a2sDialect struct {
+	name string
+	// challengeRounds is how many consecutive challenges a query answers before giving up.
+	// Source 2 servers rotate challenges and may answer a challenged request with a new one.
+	challengeRounds int
+	// reuseChallenge sends the challenge accepted for A2S_INFO with A2S_PLAYER and A2S_RULES
+	// instead of requesting a new one, saving a round trip per sub-query
+	reuseChallenge bool
+}
+
+var (
+	// a2sLegacyDialect covers GoldSrc and Source 1 servers, which issue one challenge per query type
+	a2sLegacyDialect = a2sDialect{name: "legacy", challengeRounds: 1}
+	// a2sSource2Dialect covers Source 2 servers, which always require a challenge and share it across query types
+	a2sSource2Dialect = a2sDialect{name: "source2", challengeRounds: 3, reuseChallenge: true}
+	// a2sUnknownDialect is used before the info response identifies the server, tolerating
+	// rotated challenges without assuming they are shared
+	a2sUnknownDialect = a2sDialect{name: "unknown", challengeRounds: a2sSource2Dialect.challengeRounds}
+)
+
+// a2sSource2AppIDs are the Source 2 games that answer A2S queries
+var a2sSource2AppIDs = map[uint32]bool{
+	730:     true, // Counter-Strike 2
+	570:     true, // Dota 2
+	1422450: true, // Deadlock
+}
+
+// a2sDialectFor picks the query conventions for a server from its App ID
+func a2sDialectFor(info *A2SInfo) a2sDialect {
+	if a2sSource2AppIDs[info.FullAppID()] {
+		return a2sSource2Dialect
 	}
+	return a2sLegacyDialect
+}
 
-	// Use protocol-specific game detection
-	result.Game = s.DetectGame(result)
+// subQueryDeadline caps a player or rules query to a share of the timeout, since some servers
+// never answer A2S_PLAYER or A2S_RULES and the info result should still return promptly.
+// The share is measured from now, not from the start of the query, so a slow info exchange
+// doesn't starve the sub-queries; only the remaining context time cuts it shorter. Without a
+// context deadline a query with both sub-queries can therefore take up to twice the timeout,
+// as documented on Options.Timeout.
+func (s *A2SProtocol) subQueryDeadline(ctx context.Context, opts *Options) time.Time {
+	deadline := time.Now().Add(time.Duration(float64(getTimeout(opts)) * subQueryBudget))
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	return deadline
+}
 
-	// Query players if requested
-	if opts.Players {
-		players, err := s.queryPlayers(conn, addr, getTimeout(opts))
-		if err == nil {
-			result.Players.List = players
-		} else {
-			result.Players.List = make([]Player, 0)
+// subQueryResult carries the outcome of a player or rules query run on its own socket
+type subQueryResult struct {
+	players []Player
+	rules   map[string]string
+	err     error
+}
+
+// startSubQuery runs query on a separate socket so it overlaps the info exchange and the
+// other sub-queries. The sub-query always negotiates its own challenge, which works both for
+// servers that share one challenge across query types and for those that issue one per query
+func (s *A2SProtocol) startSubQuery(ctx context.Context, addr string, opts *Options, deadline time.Time, name string, query func(conn net.Conn) subQueryResult) <-chan subQueryResult {
+	results := make(chan subQueryResult, 1)
+	go func() {
+		conn, err := setupConnection(ctx, "udp", addr, opts)
+		if err != nil {
+			results <- subQueryResult{err: err}
+			return
 		}
-	}
+		defer conn.Close()
+		conn.SetDeadline(deadline)
 
-	return result, nil
+		if opts.Debug {
+			debugLogf(opts, "A2S", "Querying %s in parallel (deadline %v)", name, deadline)
+		}
+		results <- query(conn)
+	}()
+	return results
 }
 
-func (s *A2SProtocol) queryPlayers(conn net.Conn, addr string, timeout time.Duration) ([]Player, error) {
+// queryPlayers issues A2S_PLAYER, sending infoChallenge first when the dialect shares
+// challenges and the info query needed one (0 = none known)
+func (s *A2SProtocol) queryPlayers(conn net.Conn, dialect a2sDialect, infoChallenge uint32) ([]Player, error) {
 	// A2S_PLAYER request
-	request := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x55}
-	challengeBytes := make([]byte, 4)
-	binary.LittleEndian.PutUint32(challengeBytes, 0xFFFFFFFF)
-	request = append(request, challengeBytes...)
-
-	// Send request
-	if _, err := conn.Write(request); err != nil {
+	payload, err := s.challengeQuery(conn, 0x55, 0x44, "player", dialect, infoChallenge)
+	if err != nil {
 		return nil, err
 	}
+	return s.parsePlayersResponse(payload)
+}
 
-	// Read response
-	response := make([]byte, 1400)
-	n, err := conn.Read(response)
+// queryRules issues A2S_RULES and returns the server's cvars
+func (s *A2SProtocol) queryRules(conn net.Conn, dialect a2sDialect, infoChallenge uint32) (map[string]string, error) {
+	payload, err := s.challengeQuery(conn, 0x56, 0x45, "rules", dialect, infoChallenge)
 	if err != nil {
 		return nil, err
 	}
+	return s.parseRulesResponse(payload)
+}
 
-	if n < 5 {
-		return nil, fmt.Errorf("player response too short")
+// challengeQuery sends a sub-query that must carry a challenge, answering up to the dialect's
+// number of challenges the server issues, and returns the payload after the expected response header
+func (s *A2SProtocol) challengeQuery(conn net.Conn, requestType, responseType byte, name string, dialect a2sDialect, infoChallenge uint32) ([]byte, error) {
+	// 0xFFFFFFFF asks the server for a challenge
+	challenge := uint32(0xFFFFFFFF)
+	if dialect.reuseChallenge && infoChallenge != 0 {
+		challenge = infoChallenge
 	}
 
-	// Check for challenge
-	if response[4] == 0x41 {
-		if n < 9 {
-			return nil, fmt.Errorf("player challenge too short")
-		}
-		challenge := binary.LittleEndian.Uint32(response[5:9])
-
-		// Retry with challenge
-		request = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0x55}
-		challengeBytes = make([]byte, 4)
+	for round := 0; ; round++ {
+		request := []byte{0xFF, 0xFF, 0xFF, 0xFF, requestType}
+		challengeBytes := make([]byte, 4)
 		binary.LittleEndian.PutUint32(challengeBytes, challenge)
 		request = append(request, challengeBytes...)
 
+		// Send request
 		if _, err := conn.Write(request); err != nil {
 			return nil, err
 		}
 
-		n, err = conn.Read(response)
+		// Read response
+		response, err := s.readResponse(conn)
+		n := len(response)
 		if err != nil {
 			return nil, err
 		}
+
+		if n < 5 {
+			return nil, fmt.Errorf("%s response too short", name)
+		}
+
+		// Check for challenge and retry with it
+		if response[4] == 0x41 {
+			if n < 9 {
+				return nil, fmt.Errorf("%s challenge too short", name)
+			}
+			if round >= dialect.challengeRounds {
+				return nil, fmt.Errorf("%s challenge not accepted", name)
+			}
+			challenge = binary.LittleEndian.Uint32(response[5:9])
+			continue
+		}
+
+		if n < 6 || response[4] != responseType {
+			return nil, fmt.Errorf("invalid %s response", name)
+		}
+
+		return response[5:n], nil
 	}
+}
 
-	if n < 6 || response[4] != 0x44 {
-		return nil, fmt.Errorf("invalid player response")
+// parseRulesResponse parses the A2S_RULES payload. Some servers truncate the rule list
+// without sending a split packet, so the rules read before the cut are kept.
+func (s *A2SProtocol) parseRulesResponse(data []byte) (map[string]string, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("rules response too short")
 	}
+	count := int(binary.LittleEndian.Uint16(data[:2]))
+	offset := 2
 
-	return s.parsePlayersResponse(response[5:n])
+	rules := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		name, next, err := s.readNullTerminatedString(data, offset)
+		if err != nil {
+			break
+		}
+		value, next, err := s.readNullTerminatedString(data, next)
+		if err != nil {
+			break
+		}
+		rules[name] = value
+		offset = next
+	}
+	return rules, nil
+}
+
+// nextMap returns the next map from the well-known rules, falling back to a
+// nextmap:<map> keyword for servers that only advertise it in A2S_INFO
+func (s *A2SProtocol) nextMap(rules map[string]string, keywords string) string {
+	for _, key := range a2sNextMapRules {
+		if value := strings.TrimSpace(rules[key]); value != "" {
+			return value
+		}
+	}
+	return s.keywordValue(keywords, "nextmap")
+}
+
+// readResponse reads a single A2S response, reassembling split (0xFFFFFFFE) packets
+// into the single packet layout so callers can always inspect the type at offset 4.
+// The socket is reused across exchanges, so fragments whose ID or total differ from the
+// first one's are late parts of an earlier response and are skipped.
+func (s *A2SProtocol) readResponse(conn net.Conn) ([]byte, error) {
+	buffer := make([]byte, 1400)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		return nil, err
+	}
+	if n < 4 || binary.LittleEndian.Uint32(buffer[:4]) != 0xFFFFFFFE {
+		return buffer[:n], nil
+	}
+
+	// Some servers wrap even single datagram responses in a split header with total=1
+	fragments := make(map[int][]byte)
+	headerSize := 0
+	total := 0
+	var id uint32
+	for {
+		if n < 10 {
+			return nil, fmt.Errorf("split packet too short")
+		}
+		packetID := binary.LittleEndian.Uint32(buffer[4:8])
+		if packetID&0x80000000 != 0 {
+			return nil, fmt.Errorf("compressed split packets not supported")
+		}
+
+		if headerSize == 0 {
+			headerSize = s.splitHeaderSize(buffer[:n])
+		}
+		var number, packetTotal int
+		if headerSize == 9 {
+			// GoldSource packs the fragment number and total into one byte
+			number, packetTotal = int(buffer[8]>>4), int(buffer[8]&0x0F)
+		} else {
+			packetTotal, number = int(buffer[8]), int(buffer[9])
+		}
+		if packetTotal == 0 || number >= packetTotal || n < headerSize {
+			return nil, fmt.Errorf("invalid split packet header")
+		}
+		if total == 0 {
+			id, total = packetID, packetTotal
+		}
+		if packetID == id && packetTotal == total {
+			fragments[number] = append([]byte(nil), buffer[headerSize:n]...)
+		}
+
+		if len(fragments) == total {
+			break
+		}
+		if n, err = conn.Read(buffer); err != nil {
+			return nil, fmt.Errorf("read split packet failed: %w", err)
+		}
+		if n < 4 || binary.LittleEndian.Uint32(buffer[:4]) != 0xFFFFFFFE {
+			return nil, fmt.Errorf("expected split packet")
+		}
+	}
+
+	var payload []byte
+	for i := 0; i < total; i++ {
+		payload = append(payload, fragments[i]...)
+	}
+	return payload, nil
+}
+
+// splitHeaderSize determines the split header layout by locating the 0xFFFFFFFF
+// single packet header of the reassembled payload: Source with a size field (12),
+// Source without one (10) or GoldSource (9). Later fragments reuse the first guess.
+func (s *A2SProtocol) splitHeaderSize(packet []byte) int {
+	for _, size := range []int{12, 10, 9} {
+		if len(packet) >= size+4 && binary.LittleEndian.Uint32(packet[size:size+4]) == 0xFFFFFFFF {
+			return size
+		}
+	}
+	return 12
 }
 
 func (s *A2SProtocol) parseA2SInfoResponse(data []byte) (*A2SInfo, error) {
@@ -446,6 +879,66 @@ func (s *A2SProtocol) parseA2SInfoResponse(data []byte) (*A2SInfo, error) {
 		return nil, fmt.Errorf("read version failed: %w", err)
 	}
 	info.Version = version
+	offset = newOffset
+
+	// Extra Data Flag (optional)
+	if offset >= len(data) {
+		return info, nil
+	}
+	info.ExtraDataFlag = data[offset]
+	offset++
+
+	// Game port
+	if info.ExtraDataFlag&0x80 != 0 {
+		if offset+1 >= len(data) {
+			return nil, fmt.Errorf("missing EDF port")
+		}
+		info.Port = binary.LittleEndian.Uint16(data[offset : offset+2])
+		offset += 2
+	}
+
+	// Server SteamID
+	if info.ExtraDataFlag&0x10 != 0 {
+		if offset+7 >= len(data) {
+			return nil, fmt.Errorf("missing EDF steam ID")
+		}
+		info.SteamID = binary.LittleEndian.Uint64(data[offset : offset+8])
+		offset += 8
+	}
+
+	// SourceTV port and name
+	if info.ExtraDataFlag&0x40 != 0 {
+		if offset+1 >= len(data) {
+			return nil, fmt.Errorf("missing EDF SourceTV port")
+		}
+		info.SourceTVPort = binary.LittleEndian.Uint16(data[offset : offset+2])
+		offset += 2
+
+		tvName, newOffset, err := s.readNullTerminatedString(data, offset)
+		if err != nil {
+			return nil, fmt.Errorf("read SourceTV name failed: %w", err)
+		}
+		info.SourceTVName = tvName
+		offset = newOffset
+	}
+
+	// Keywords
+	if info.ExtraDataFlag&0x20 != 0 {
+		keywords, newOffset, err := s.readNullTerminatedString(data, offset)
+		if err != nil {
+			return nil, fmt.Errorf("read keywords failed: %w", err)
+		}
+		info.Keywords = keywords
+		offset = newOffset
+	}
+
+	// Full 64-bit game ID, the low 24 bits hold the real App ID
+	if info.ExtraDataFlag&0x01 != 0 {
+		if offset+7 >= len(data) {
+			return nil, fmt.Errorf("missing EDF game ID")
+		}
+		info.GameID = binary.LittleEndian.Uint64(data[offset : offset+8])
+	}
 
 	return info, nil
 }
@@ -487,8 +980,9 @@ func (s *A2SProtocol) parsePlayersResponse(data []byte) ([]Player, error) {
 		}
 		durationBits := binary.LittleEndian.Uint32(data[offset : offset+4])
 		durationFloat := math.Float32frombits(durationBits)
-		// Round to nearest second
-		duration := time.Duration(math.Round(float64(durationFloat))) * time.Second
+		// Keep the fractional seconds, which Player.JoinedAt needs to stay consistent across
+		// polls, rounded to the millisecond to drop float32 noise
+		duration := time.Duration(float64(durationFloat) * float64(time.Second)).Round(time.Millisecond)
 		offset += 4
 
 		players = append(players, Player{
@@ -512,6 +1006,19 @@ func (s *A2SProtocol) readNullTerminatedString(data []byte, offset int) (string,
 	return string(data[start:offset]), offset + 1, nil
 }
 
+// keywordValue returns the value of a "key:value" or "key=value" token from the comma separated keywords
+func (s *A2SProtocol) keywordValue(keywords, key string) string {
+	for _, token := range strings.Split(keywords, ",") {
+		token = strings.TrimSpace(token)
+		for _, sep := range []string{":", "="} {
+			if k, v, found := strings.Cut(token, sep); found && strings.EqualFold(k, key) {
+				return strings.TrimSpace(v)
+			}
+		}
+	}
+	return ""
+}
+
 // detectGameType has been moved to central game detector in gamedetector.go
 
 // A2SInfo represents the parsed A2S_INFO response
@@ -530,6 +1037,24 @@ type A2SInfo struct {
 	Visibility  uint8
 	VAC         uint8
 	Version     string
+
+	// Extra Data Flag fields, only set when the matching EDF bit is present
+	ExtraDataFlag uint8
+	Port          uint16
+	SteamID       uint64
+	SourceTVPort  uint16
+	SourceTVName  string
+	Keywords      string
+	GameID        uint64
+}
+
+// FullAppID returns the Steam App ID, preferring the EDF game ID since the
+// legacy AppID field is only 16 bits wide and truncates newer App IDs
+func (i *A2SInfo) FullAppID() uint32 {
+	if i.ExtraDataFlag&0x01 != 0 && i.GameID != 0 {
+		return uint32(i.GameID & 0xFFFFFF)
+	}
+	return uint32(i.AppID)
 }
 
 // detectByAppID determines game type from Steam App ID
@@ -562,8 +1087,16 @@ func (s *A2SProtocol) detectByAppID(appIDStr string) string {
 		return "rust"
 	case 346110:
 		return "ark-survival-evolved"
-	case 222880:
+	case 222880: // The original Insurgency, not Sandstorm
 		return "insurgency"
+	case 581320, 581330: // Game and dedicated server App IDs
+		return "insurgency-sandstorm"
+	case 228380, 361580: // Game and dedicated server App IDs
+		return "wreckfest"
+	case 35450, 212542: // Game and dedicated server App IDs
+		return "red-orchestra-2"
+	case 418460, 418480: // Game and dedicated server App IDs
+		return "rising-storm-2"
 	case 108600:
 		return "project-zomboid"
 	case 526870:
@@ -578,6 +1111,16 @@ func (s *A2SProtocol) detectByAppID(appIDStr string) string {
 		return "dayz"
 	case 489940:
 		return "battalion-1944"
+	case 244850, 298740: // Game and dedicated server App IDs
+		return "space-engineers"
+	case 393380, 403240: // Game and dedicated server App IDs
+		return "squad"
+	case 736220:
+		return "post-scriptum"
+	case 629760, 629800: // Game and dedicated server App IDs
+		return "mordhau"
+	case 602960, 1026340: // Game and dedicated server App IDs
+		return "barotrauma"
 	}
 	
 	return ""
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,6 +35,21 @@ func createA2SInfo(name, mapName, folder, game, version string, appID uint16, pl
 	}
 }
 
+func withKeywords(info *A2SInfo, keywords string) {
+	info.ExtraDataFlag |= 0x20
+	info.Keywords = keywords
+}
+
+func withGamePort(info *A2SInfo, port uint16) {
+	info.ExtraDataFlag |= 0x80
+	info.Port = port
+}
+
+func withGameID(info *A2SInfo, appID uint64) {
+	info.ExtraDataFlag |= 0x01
+	info.GameID = appID
+}
+
 // mockA2SServer simulates an A2S server for testing purposes.
 type mockA2SServer struct {
 	t                *testing.T
@@ -41,6 +58,16 @@ type mockA2SServer struct {
 	players          []a2sPlayer
 	requireChallenge bool
 	challengeValue   uint32
+	splitResponses   bool
+	ignorePlayers    bool
+	rules            map[string]string
+	infoDelay        time.Duration
+	playerDelay      time.Duration
+	rulesDelay       time.Duration
+
+	mu               sync.Mutex
+	requests         map[byte]int // Requests received per type
+	rotateChallenges int          // Challenged requests still to answer with a fresh challenge
 }
 
 type a2sPlayer struct {
@@ -82,11 +109,93 @@ func (s *mockA2SServer) setPlayers(players []a2sPlayer) {
 	s.players = players
 }
 
+// setDelays adds extra latency before answering A2S_INFO and A2S_PLAYER requests.
+func (s *mockA2SServer) setDelays(info, players time.Duration) {
+	s.infoDelay = info
+	s.playerDelay = players
+}
+
+// setRotateChallenges makes the server answer the next n challenged requests with a new
+// challenge, like Source 2 servers rotating their challenge.
+func (s *mockA2SServer) setRotateChallenges(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotateChallenges = n
+}
+
+// requestCount returns how many requests of the given type the server received.
+func (s *mockA2SServer) requestCount(requestType byte) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[requestType]
+}
+
+// currentChallenge returns the challenge the server currently accepts.
+func (s *mockA2SServer) currentChallenge() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.challengeValue
+}
+
+// rotateChallenge answers a challenged request with a new challenge if rotation is pending.
+func (s *mockA2SServer) rotateChallenge(addr net.Addr) bool {
+	s.mu.Lock()
+	if s.rotateChallenges == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	s.rotateChallenges--
+	s.challengeValue++
+	challenge := s.challengeValue
+	s.mu.Unlock()
+
+	var response bytes.Buffer
+	response.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x41}) // Challenge header
+	binary.Write(&response, binary.LittleEndian, challenge)
+	s.write(response.Bytes(), addr)
+	return true
+}
+
+// setRulesDelay adds extra latency before answering A2S_RULES requests.
+func (s *mockA2SServer) setRulesDelay(d time.Duration) {
+	s.rulesDelay = d
+}
+
+// setRules sets the rules returned for A2S_RULES requests.
+func (s *mockA2SServer) setRules(rules map[string]string) {
+	s.rules = rules
+}
+
 // setRequireChallenge configures whether the server requires challenge for A2S_INFO.
 func (s *mockA2SServer) setRequireChallenge(require bool) {
 	s.requireChallenge = require
 }
 
+// setIgnorePlayers makes the server silently drop A2S_PLAYER requests.
+func (s *mockA2SServer) setIgnorePlayers(ignore bool) {
+	s.ignorePlayers = ignore
+}
+
+// setSplitResponses wraps every response in a single fragment split packet header.
+func (s *mockA2SServer) setSplitResponses(split bool) {
+	s.splitResponses = split
+}
+
+// write sends a response, optionally wrapped as a Source split packet with total=1.
+func (s *mockA2SServer) write(data []byte, addr net.Addr) {
+	if s.splitResponses {
+		var packet bytes.Buffer
+		packet.Write([]byte{0xFE, 0xFF, 0xFF, 0xFF})               // Split header
+		binary.Write(&packet, binary.LittleEndian, uint32(0x1234)) // Packet ID
+		packet.WriteByte(1)                                        // Total
+		packet.WriteByte(0)                                        // Number
+		binary.Write(&packet, binary.LittleEndian, uint16(1248))   // Max packet size
+		packet.Write(data)
+		data = packet.Bytes()
+	}
+	s.listener.WriteTo(data, addr)
+}
+
 // handleRequests processes incoming UDP packets.
 func (s *mockA2SServer) handleRequests() {
 	buffer := make([]byte, 1400)
@@ -95,7 +204,9 @@ func (s *mockA2SServer) handleRequests() {
 		if err != nil {
 			return // Listener closed
 		}
-		go s.handlePacket(buffer[:n], addr)
+		packet := make([]byte, n)
+		copy(packet, buffer[:n])
+		go s.handlePacket(packet, addr)
 	}
 }
 
@@ -110,26 +221,43 @@ func (s *mockA2SServer) handlePacket(data []byte, addr net.Addr) {
 		return
 	}
 
+	s.mu.Lock()
+	if s.requests == nil {
+		s.requests = make(map[byte]int)
+	}
+	s.requests[data[4]]++
+	s.mu.Unlock()
+
 	// Add a small delay to simulate network latency
 	time.Sleep(5 * time.Millisecond)
 
 	switch data[4] {
 	case 0x54: // A2S_INFO
+		time.Sleep(s.infoDelay)
 		s.handleInfoRequest(data, addr)
 	case 0x55: // A2S_PLAYER
-		s.handlePlayerRequest(data, addr)
+		time.Sleep(s.playerDelay)
+		if !s.ignorePlayers {
+			s.handlePlayerRequest(data, addr)
+		}
+	case 0x56: // A2S_RULES
+		time.Sleep(s.rulesDelay)
+		s.handleRulesRequest(data, addr)
 	}
 }
 
 // handleInfoRequest handles A2S_INFO requests.
 func (s *mockA2SServer) handleInfoRequest(data []byte, addr net.Addr) {
 	// Check if challenge is present and required
-	if s.requireChallenge && len(data) < 24 {
+	if s.requireChallenge && len(data) < 29 { // 25 byte request + 4 byte challenge
 		// Send challenge response
 		var response bytes.Buffer
 		response.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x41}) // Challenge header
-		binary.Write(&response, binary.LittleEndian, s.challengeValue)
-		s.listener.WriteTo(response.Bytes(), addr)
+		binary.Write(&response, binary.LittleEndian, s.currentChallenge())
+		s.write(response.Bytes(), addr)
+		return
+	}
+	if s.requireChallenge && s.rotateChallenge(addr) {
 		return
 	}
 
@@ -184,7 +312,43 @@ func (s *mockA2SServer) handleInfoRequest(data []byte, addr net.Addr) {
 	response.WriteString(s.infoResponse.Version)
 	response.WriteByte(0)
 
-	s.listener.WriteTo(response.Bytes(), addr)
+	// Extra Data Flag
+	if edf := s.infoResponse.ExtraDataFlag; edf != 0 {
+		response.WriteByte(edf)
+		if edf&0x80 != 0 {
+			binary.Write(&response, binary.LittleEndian, s.infoResponse.Port)
+		}
+		if edf&0x10 != 0 {
+			binary.Write(&response, binary.LittleEndian, s.infoResponse.SteamID)
+		}
+		if edf&0x40 != 0 {
+			binary.Write(&response, binary.LittleEndian, s.infoResponse.SourceTVPort)
+			response.WriteString(s.infoResponse.SourceTVName)
+			response.WriteByte(0)
+		}
+		if edf&0x20 != 0 {
+			response.WriteString(s.infoResponse.Keywords)
+			response.WriteByte(0)
+		}
+		if edf&0x01 != 0 {
+			binary.Write(&response, binary.LittleEndian, s.infoResponse.GameID)
+		}
+	}
+
+	s.write(response.Bytes(), addr)
+}
+
+// acceptChallenge reports whether a sub-query carries the current challenge, otherwise
+// answering it with the challenge to use (or a rotated one while rotation is pending).
+func (s *mockA2SServer) acceptChallenge(data []byte, addr net.Addr) bool {
+	if binary.LittleEndian.Uint32(data[5:9]) != s.currentChallenge() {
+		var response bytes.Buffer
+		response.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x41}) // Challenge header
+		binary.Write(&response, binary.LittleEndian, s.currentChallenge())
+		s.write(response.Bytes(), addr)
+		return false
+	}
+	return !s.rotateChallenge(addr)
 }
 
 // handlePlayerRequest handles A2S_PLAYER requests.
@@ -194,13 +358,7 @@ func (s *mockA2SServer) handlePlayerRequest(data []byte, addr net.Addr) {
 	}
 
 	// Check challenge
-	challenge := binary.LittleEndian.Uint32(data[5:9])
-	if challenge == 0xFFFFFFFF {
-		// Send challenge response
-		var response bytes.Buffer
-		response.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x41}) // Challenge header
-		binary.Write(&response, binary.LittleEndian, s.challengeValue)
-		s.listener.WriteTo(response.Bytes(), addr)
+	if !s.acceptChallenge(data, addr) {
 		return
 	}
 
@@ -222,7 +380,32 @@ func (s *mockA2SServer) handlePlayerRequest(data []byte, addr net.Addr) {
 		binary.Write(&response, binary.LittleEndian, bits)
 	}
 
-	s.listener.WriteTo(response.Bytes(), addr)
+	s.write(response.Bytes(), addr)
+}
+
+// handleRulesRequest handles A2S_RULES requests.
+func (s *mockA2SServer) handleRulesRequest(data []byte, addr net.Addr) {
+	if len(data) < 9 {
+		return
+	}
+
+	// Rules always require a challenge
+	if !s.acceptChallenge(data, addr) {
+		return
+	}
+
+	// Build A2S_RULES response
+	var response bytes.Buffer
+	response.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x45}) // A2S_RULES response header
+	binary.Write(&response, binary.LittleEndian, uint16(len(s.rules)))
+	for name, value := range s.rules {
+		response.WriteString(name)
+		response.WriteByte(0)
+		response.WriteString(value)
+		response.WriteByte(0)
+	}
+
+	s.write(response.Bytes(), addr)
 }
 
 func TestA2SProtocol_Query(t *testing.T) {
@@ -260,6 +443,7 @@ func TestA2SProtocol_Query(t *testing.T) {
 		playersCurrent: 16,
 		playersMax:     32,
 	})
+	assert.Equal(t, "17", info.Extra["protocol_version"])
 }
 
 func TestA2SProtocol_Query_WithChallenge(t *testing.T) {
@@ -316,7 +500,7 @@ func TestA2SProtocol_Query_WithPlayers(t *testing.T) {
 	server.setPlayers([]a2sPlayer{
 		{name: "Player1", score: 100, duration: 3600},
 		{name: "Player2", score: 50, duration: 1800},
-		{name: "Player3", score: 75, duration: 900},
+		{name: "Player3", score: 75, duration: 900.25}, // Fractional seconds are kept
 	})
 	defer server.Close()
 
@@ -343,7 +527,7 @@ func TestA2SProtocol_Query_WithPlayers(t *testing.T) {
 		playerDurations: []time.Duration{
 			time.Duration(3600 * time.Second),
 			time.Duration(1800 * time.Second),
-			time.Duration(900 * time.Second),
+			time.Duration(900*time.Second + 250*time.Millisecond),
 		},
 	})
 }
@@ -387,6 +571,51 @@ func TestA2SProtocol_Query_EmptyPlayerList(t *testing.T) {
 	})
 }
 
+func TestA2SProtocol_Query_ProjectZomboidQuirks(t *testing.T) {
+	// 1. Setup mock server with a Project Zomboid response and no player list
+	mockResponse := createA2SInfo(
+		"Zomboid Server",
+		"Muldraugh, KY",
+		"zomboid",
+		"Project Zomboid",
+		"41.78.16",
+		uint16(108600&0xFFFF), // Truncated legacy App ID field
+		4,
+		32,
+	)
+	withGameID(&mockResponse, 108600)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setPlayers([]a2sPlayer{})
+	defer server.Close()
+
+	// 2. Query the mock server with players enabled
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout: 5 * time.Second,
+		Players: true,
+	}
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+
+	// 3. Assert the results
+	assert.NoError(t, err)
+	assertA2SServerInfo(t, info, expectedA2SServerInfo{
+		online:         true,
+		name:           "Zomboid Server",
+		game:           "project-zomboid",
+		map_:           "Muldraugh, KY",
+		version:        "41.78.16",
+		playersCurrent: 4,
+		playersMax:     32,
+		playerNames:    []string{},
+	})
+
+	_, portStr, _ := net.SplitHostPort(server.Addr())
+	port, _ := strconv.Atoi(portStr)
+	assert.Equal(t, strconv.Itoa(port+1), info.Extra["secondary_port"])
+	assert.Equal(t, "true", info.Extra["player_list_unavailable"])
+}
+
 func TestA2SProtocol_GameDetection(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -480,6 +709,8 @@ func assertA2SServerInfo(t *testing.T, info *ServerInfo, expected expectedA2SSer
 	if info.Extra != nil {
 		assert.Contains(t, info.Extra, "game", "Extra should contain game description")
 		assert.Contains(t, info.Extra, "app_id", "Extra should contain app ID")
+		assert.Equal(t, "dedicated", info.Extra["server_type"], "Extra should contain readable server type")
+		assert.Equal(t, "linux", info.Extra["os"], "Extra should contain readable OS")
 	}
 	
 	// Player information
@@ -501,4 +732,563 @@ func assertA2SServerInfo(t *testing.T, info *ServerInfo, expected expectedA2SSer
 	} else {
 		assert.Nil(t, info.Players.List)
 	}
-}
\ No newline at end of file
+}
+func TestA2SProtocol_Query_Capabilities(t *testing.T) {
+	// 1. Setup mock server that requires a challenge and has players online
+	mockResponse := createA2SInfo(
+		"Capable Server",
+		"de_inferno",
+		"csgo",
+		"Counter-Strike 2",
+		"1.40.0.0",
+		730,
+		12,
+		24,
+	)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setRequireChallenge(true)
+	defer server.Close()
+
+	// 2. Query the mock server with capability detection enabled
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout:      5 * time.Second,
+		Capabilities: true,
+	}
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+
+	// 3. Assert the capability hints
+	assert.NoError(t, err)
+	assert.Equal(t, "true", info.Extra["challenge_required"])
+	assert.Equal(t, "true", info.Extra["players_available"])
+}
+
+func TestA2SProtocol_Query_CapabilitiesProxy(t *testing.T) {
+	// HLTV proxies relay spectators and have no player list; GoldSrc reports the type in uppercase
+	for _, serverType := range []byte{'p', 'P'} {
+		mockResponse := createA2SInfo("HLTV Proxy", "de_dust2", "cstrike", "Counter-Strike", "1.1.2.7", 10, 5, 100)
+		mockResponse.ServerType = serverType
+
+		server := newMockA2SServer(t, mockResponse)
+		protocol := &A2SProtocol{}
+		info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, Capabilities: true})
+		server.Close()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "false", info.Extra["players_available"], string(serverType))
+	}
+}
+
+func TestA2SProtocol_Query_RegionFromKeywords(t *testing.T) {
+	// 1. Setup mock server advertising a region in its keywords
+	mockResponse := createA2SInfo(
+		"EU Rust Server",
+		"Procedural Map",
+		"rust",
+		"Rust",
+		"2577",
+		uint16(252490&0xFFFF),
+		50,
+		200,
+	)
+	withKeywords(&mockResponse, "mp200,cp50,region:eu,lang:de,born1700000000")
+	withGameID(&mockResponse, 252490)
+
+	server := newMockA2SServer(t, mockResponse)
+	defer server.Close()
+
+	// 2. Query the mock server
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout: 5 * time.Second,
+	}
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+
+	// 3. Assert the region was extracted and the game detected from the EDF App ID
+	assert.NoError(t, err)
+	assert.Equal(t, "rust", info.Game)
+	assert.Equal(t, "eu", info.Region)
+	assert.Equal(t, "de", info.Language)
+	assert.Equal(t, "mp200,cp50,region:eu,lang:de,born1700000000", info.Extra["keywords_raw"])
+}
+
+func TestA2SProtocol_Query_GamePortFromEDF(t *testing.T) {
+	mockResponse := createA2SInfo("Modded Ark", "TheIsland", "ark_survival_evolved", "ARK: Survival Evolved", "1.0", uint16(346110&0xFFFF), 3, 70)
+	withGamePort(&mockResponse, 7781)
+	withGameID(&mockResponse, 346110)
+	server := newMockA2SServer(t, mockResponse)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "7781", info.Extra["game_port"])
+	assert.Equal(t, 7781, info.GamePort())
+}
+
+func TestA2SProtocol_Query_SingleFragmentSplitPacket(t *testing.T) {
+	// 1. Setup mock server that wraps every response in a total=1 split header
+	mockResponse := createA2SInfo(
+		"Split Server",
+		"ctf_2fort",
+		"tf",
+		"Team Fortress 2",
+		"8835751",
+		440,
+		2,
+		24,
+	)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setRequireChallenge(true)
+	server.setSplitResponses(true)
+	server.setPlayers([]a2sPlayer{
+		{name: "Scout", score: 5, duration: 120},
+		{name: "Heavy", score: 9, duration: 300},
+	})
+	defer server.Close()
+
+	// 2. Query the mock server with players enabled
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout: 5 * time.Second,
+		Players: true,
+	}
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+
+	// 3. Assert the split responses parsed like regular ones
+	assert.NoError(t, err)
+	assertA2SServerInfo(t, info, expectedA2SServerInfo{
+		online:         true,
+		name:           "Split Server",
+		game:           "team-fortress-2",
+		map_:           "ctf_2fort",
+		version:        "8835751",
+		playersCurrent: 2,
+		playersMax:     24,
+		playerNames:    []string{"Scout", "Heavy"},
+		playerScores:   []int{5, 9},
+	})
+}
+
+func TestA2SProtocol_ReadResponse_InterleavedSplitPackets(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	defer server.Close()
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial mock server: %v", err)
+	}
+	defer conn.Close()
+
+	// Source split fragment: header, ID, total, number and a size field
+	fragment := func(id uint32, total, number byte, payload []byte) []byte {
+		var packet bytes.Buffer
+		binary.Write(&packet, binary.LittleEndian, uint32(0xFFFFFFFE))
+		binary.Write(&packet, binary.LittleEndian, id)
+		packet.WriteByte(total)
+		packet.WriteByte(number)
+		binary.Write(&packet, binary.LittleEndian, uint16(1248))
+		packet.Write(payload)
+		return packet.Bytes()
+	}
+	current := []byte("\xFF\xFF\xFF\xFFDcurrent response")
+	stale := []byte("\xFF\xFF\xFF\xFFIstale response")
+
+	// Tell the server our address, then interleave the late fragments of an earlier
+	// response and one with a matching ID but a different total
+	conn.Write([]byte{0})
+	_, client, err := server.ReadFrom(make([]byte, 1))
+	if err != nil {
+		t.Fatalf("Failed to read from client: %v", err)
+	}
+	for _, packet := range [][]byte{
+		fragment(7, 2, 0, current[:10]),
+		fragment(3, 2, 0, stale[:10]),
+		fragment(7, 3, 1, []byte("spliced")),
+		fragment(3, 2, 1, stale[10:]),
+		fragment(7, 2, 1, current[10:]),
+	} {
+		server.WriteTo(packet, client)
+	}
+
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	protocol := &A2SProtocol{}
+	payload, err := protocol.readResponse(conn)
+
+	assert.NoError(t, err)
+	assert.Equal(t, current, payload)
+}
+
+func TestA2SProtocol_Probe(t *testing.T) {
+	server := newMockA2SServer(t, createA2SInfo("Probe Server", "de_nuke", "csgo", "Counter-Strike 2", "1.0", 730, 0, 10))
+	server.setRequireChallenge(true)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	err := protocol.Probe(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+}
+
+func TestA2SProtocol_Query_PlayerQueryHangs(t *testing.T) {
+	// 1. Setup mock server that answers info but never answers A2S_PLAYER
+	mockResponse := createA2SInfo("Hanging Server", "cp_badlands", "tf", "Team Fortress 2", "1.0", 440, 6, 24)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setIgnorePlayers(true)
+	defer server.Close()
+
+	// 2. Query the mock server with players enabled
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout: 2 * time.Second,
+		Players: true,
+	}
+	start := time.Now()
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+	elapsed := time.Since(start)
+
+	// 3. The info result comes back within the player budget with an annotated empty list
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Empty(t, info.Players.List)
+	assert.Equal(t, "true", info.Extra["player_list_unavailable"])
+	assert.Less(t, elapsed, 1500*time.Millisecond)
+}
+
+func TestA2SProtocol_Query_ServerTypeAndOS(t *testing.T) {
+	// 1. Setup mock Windows listen server using GoldSource style uppercase bytes
+	mockResponse := createA2SInfo("Listen Server", "crossfire", "valve", "Half-Life", "1.1.2.7", 320, 1, 8)
+	mockResponse.ServerType = 'L'
+	mockResponse.Environment = 'w'
+
+	server := newMockA2SServer(t, mockResponse)
+	defer server.Close()
+
+	// 2. Query the mock server
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+
+	// 3. Assert the readable values
+	assert.NoError(t, err)
+	assert.Equal(t, "listen", info.Extra["server_type"])
+	assert.Equal(t, "windows", info.Extra["os"])
+}
+
+func TestA2SProtocol_Query_FormattedName(t *testing.T) {
+	mockResponse := createA2SInfo("<color=#ff0000>EU</color> <b>Main</b>", "gm_construct", "garrysmod", "Garry's Mod", "2023.06.28", 4000, 0, 32)
+
+	server := newMockA2SServer(t, mockResponse)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "EU Main", info.Name)
+	assert.Equal(t, "<color=#ff0000>EU</color> <b>Main</b>", info.Extra["name_raw"])
+
+	// Plain names carry no raw copy
+	server = newMockA2SServer(t, createA2SInfo("Plain Server", "de_dust2", "csgo", "Counter-Strike", "1.38", 730, 0, 20))
+	defer server.Close()
+	info, err = protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, "Plain Server", info.Name)
+	assert.NotContains(t, info.Extra, "name_raw")
+}
+
+func TestA2SProtocol_Query_BotCount(t *testing.T) {
+	mockResponse := createA2SInfo("Bot Server", "de_dust2", "csgo", "Counter-Strike", "1.38", 730, 12, 20)
+	mockResponse.Bots = 10
+
+	server := newMockA2SServer(t, mockResponse)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 12, info.Players.Current)
+	assert.Equal(t, "10", info.Extra["bots"])
+}
+
+func TestA2SProtocol_Query_ParallelPlayers(t *testing.T) {
+	// 1. Setup mock server that requires challenges for info and players
+	mockResponse := createA2SInfo("TF2 Server", "cp_dustbowl", "tf", "Team Fortress 2", "1.5.2.1", 440, 2, 32)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setRequireChallenge(true)
+	server.setPlayers([]a2sPlayer{
+		{name: "Player1", score: 100, duration: 3600},
+		{name: "Player2", score: 50, duration: 1800},
+	})
+	defer server.Close()
+
+	// 2. Query with the player query running on its own socket
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout:         5 * time.Second,
+		Players:         true,
+		ParallelQueries: true,
+	}
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+
+	// 3. The result matches a sequential query
+	assert.NoError(t, err)
+	assert.Equal(t, "TF2 Server", info.Name)
+	assert.Len(t, info.Players.List, 2)
+	assert.Equal(t, "Player1", info.Players.List[0].Name)
+	assert.Equal(t, "Player2", info.Players.List[1].Name)
+	assert.NotContains(t, info.Extra, "player_list_unavailable")
+}
+
+func TestA2SProtocol_Query_ParallelPlayerQueryHangs(t *testing.T) {
+	// 1. Setup mock server that never answers A2S_PLAYER
+	mockResponse := createA2SInfo("Hanging Server", "cp_badlands", "tf", "Team Fortress 2", "1.0", 440, 6, 24)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setIgnorePlayers(true)
+	defer server.Close()
+
+	// 2. Query in parallel mode
+	protocol := &A2SProtocol{}
+	opts := &Options{
+		Timeout:         2 * time.Second,
+		Players:         true,
+		ParallelQueries: true,
+	}
+	start := time.Now()
+	info, err := protocol.Query(context.Background(), server.Addr(), opts)
+	elapsed := time.Since(start)
+
+	// 3. The player budget still bounds the query
+	assert.NoError(t, err)
+	assert.Empty(t, info.Players.List)
+	assert.Equal(t, "true", info.Extra["player_list_unavailable"])
+	assert.Less(t, elapsed, 1500*time.Millisecond)
+}
+
+func TestA2SProtocol_Query_PingSamples(t *testing.T) {
+	// 1. Setup mock server that requires a challenge, so samples must reuse it
+	mockResponse := createA2SInfo("Ping Server", "de_dust2", "csgo", "Counter-Strike 2", "1.0", 730, 0, 10)
+
+	server := newMockA2SServer(t, mockResponse)
+	server.setRequireChallenge(true)
+	defer server.Close()
+
+	// 2. Query with several samples
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, PingSamples: 3})
+
+	// 3. The mock adds 5ms per request, so every sample is at least that
+	assert.NoError(t, err)
+	assert.Equal(t, "Ping Server", info.Name)
+	pingMin, _ := strconv.Atoi(info.Extra["ping_min"])
+	pingMax, _ := strconv.Atoi(info.Extra["ping_max"])
+	assert.GreaterOrEqual(t, pingMin, 5)
+	assert.LessOrEqual(t, pingMin, info.Ping)
+	assert.GreaterOrEqual(t, pingMax, info.Ping)
+}
+
+func TestA2SProtocol_Query_DetectsByFullAppID(t *testing.T) {
+	tests := []struct {
+		name         string
+		appID        uint64
+		expectedGame string
+	}{
+		{"Space Engineers", 244850, "space-engineers"},
+		{"Space Engineers dedicated server", 298740, "space-engineers"},
+		{"Squad", 393380, "squad"},
+		{"Squad dedicated server", 403240, "squad"},
+		{"Post Scriptum", 736220, "post-scriptum"},
+		{"Mordhau", 629760, "mordhau"},
+		{"Insurgency", 222880, "insurgency"},
+		{"Insurgency: Sandstorm", 581320, "insurgency-sandstorm"},
+		{"Insurgency: Sandstorm dedicated server", 581330, "insurgency-sandstorm"},
+		{"Wreckfest", 228380, "wreckfest"},
+		{"Wreckfest dedicated server", 361580, "wreckfest"},
+		{"Red Orchestra 2", 35450, "red-orchestra-2"},
+		{"Red Orchestra 2 dedicated server", 212542, "red-orchestra-2"},
+		{"Rising Storm 2: Vietnam", 418460, "rising-storm-2"},
+		{"Rising Storm 2: Vietnam dedicated server", 418480, "rising-storm-2"},
+		{"Barotrauma", 602960, "barotrauma"},
+		{"Barotrauma dedicated server", 1026340, "barotrauma"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Setup mock server reporting the App ID through the EDF game ID
+			mockResponse := createA2SInfo(tt.name, "map", "folder", tt.name, "1.0", uint16(tt.appID&0xFFFF), 1, 16)
+			withGameID(&mockResponse, tt.appID)
+
+			server := newMockA2SServer(t, mockResponse)
+			defer server.Close()
+
+			// 2. Query the mock server
+			protocol := &A2SProtocol{}
+			info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+
+			// 3. The full App ID identifies the game
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedGame, info.Game)
+			assert.Equal(t, strconv.FormatUint(tt.appID, 10), info.Extra["app_id"])
+		})
+	}
+}
+
+func TestA2SProtocol_Query_RulesAndNextMap(t *testing.T) {
+	server := newMockA2SServer(t, createA2SInfo("Rotation Server", "de_dust2", "csgo", "Counter-Strike", "1.38", 730, 10, 20))
+	server.setRules(map[string]string{"mp_timelimit": "30", "sm_nextmap": "de_inferno"})
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, Rules: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "30", info.Rules["mp_timelimit"])
+	assert.Equal(t, "de_inferno", info.Extra["next_map"])
+}
+
+func TestA2SProtocol_Query_NextMapFromKeywords(t *testing.T) {
+	mockResponse := createA2SInfo("Keyword Server", "de_dust2", "csgo", "Counter-Strike", "1.38", 730, 10, 20)
+	withKeywords(&mockResponse, "secure,nextmap:de_nuke")
+	server := newMockA2SServer(t, mockResponse)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Nil(t, info.Rules)
+	assert.Equal(t, "de_nuke", info.Extra["next_map"])
+}
+
+func TestA2SProtocol_Query_SlowInfoLeavesTimeForPlayers(t *testing.T) {
+	// Info uses most of the timeout; the two player round trips need more than what remains of it
+	server := newMockA2SServer(t, createA2SInfo("Slow Server", "cp_badlands", "tf", "Team Fortress 2", "1.0", 440, 1, 24))
+	server.setPlayers([]a2sPlayer{{name: "Patient", score: 1, duration: 10}})
+	server.setDelays(900*time.Millisecond, 100*time.Millisecond)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.NotContains(t, info.Extra, "player_list_unavailable")
+	assert.Len(t, info.Players.List, 1)
+
+	// A context deadline still bounds the sub-query
+	server.setDelays(0, 300*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	info, err = protocol.Query(ctx, server.Addr(), &Options{Timeout: time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "true", info.Extra["player_list_unavailable"])
+}
+
+func TestA2SProtocol_Query_ParallelMatchesSequential(t *testing.T) {
+	mockResponse := createA2SInfo("Deep Server", "ctf_2fort", "tf", "Team Fortress 2", "1.0", 440, 2, 24)
+	withKeywords(&mockResponse, "region:eu,nextmap:pl_upward")
+	server := newMockA2SServer(t, mockResponse)
+	server.setRequireChallenge(true)
+	server.setPlayers([]a2sPlayer{
+		{name: "Scout", score: 12, duration: 600},
+		{name: "Heavy", score: 30, duration: 1200},
+	})
+	server.setRules(map[string]string{"mp_timelimit": "30", "sv_tags": "payload"})
+	server.setDelays(100*time.Millisecond, 100*time.Millisecond)
+	server.setRulesDelay(100 * time.Millisecond)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	query := func(parallel bool) (*ServerInfo, time.Duration) {
+		start := time.Now()
+		info, err := protocol.Query(context.Background(), server.Addr(), &Options{
+			Timeout:         5 * time.Second,
+			Players:         true,
+			Rules:           true,
+			ParallelQueries: parallel,
+		})
+		elapsed := time.Since(start)
+		assert.NoError(t, err)
+		info.Ping = 0
+		return info, elapsed
+	}
+
+	sequential, sequentialElapsed := query(false)
+	parallel, parallelElapsed := query(true)
+
+	// Sequential mode pays info, then two round trips each for players and rules, while
+	// parallel mode overlaps them, so it should take well under three quarters of the time
+	assert.Equal(t, sequential, parallel)
+	assert.Len(t, parallel.Players.List, 2)
+	assert.Len(t, parallel.Rules, 2)
+	assert.Less(t, parallelElapsed, sequentialElapsed*3/4)
+}
+
+func TestA2SProtocol_Query_ParallelRulesQueryHangs(t *testing.T) {
+	server := newMockA2SServer(t, createA2SInfo("Quiet Rules", "cp_badlands", "tf", "Team Fortress 2", "1.0", 440, 6, 24))
+	server.setRulesDelay(time.Hour)
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	start := time.Now()
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 2 * time.Second, Rules: true, ParallelQueries: true})
+
+	assert.NoError(t, err)
+	assert.Nil(t, info.Rules)
+	assert.Equal(t, "true", info.Extra["rules_unavailable"])
+	assert.Less(t, time.Since(start), 1500*time.Millisecond)
+}
+
+func TestA2SProtocol_Query_ChallengeReuseByDialect(t *testing.T) {
+	tests := []struct {
+		name            string
+		appID           uint16
+		wantSubRequests int
+	}{
+		{"source 2 shares the info challenge", 730, 1},
+		{"legacy negotiates per query", 440, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockA2SServer(t, createA2SInfo("Dialect Server", "de_mirage", "csgo", "Counter-Strike 2", "1.0", tt.appID, 1, 10))
+			server.setRequireChallenge(true)
+			server.setPlayers([]a2sPlayer{{name: "Player1", score: 5, duration: 60}})
+			server.setRules(map[string]string{"mp_timelimit": "20"})
+			defer server.Close()
+
+			protocol := &A2SProtocol{}
+			info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, Players: true, Rules: true})
+
+			assert.NoError(t, err)
+			assert.Len(t, info.Players.List, 1)
+			assert.Equal(t, "20", info.Rules["mp_timelimit"])
+			assert.Equal(t, tt.wantSubRequests, server.requestCount(0x55))
+			assert.Equal(t, tt.wantSubRequests, server.requestCount(0x56))
+		})
+	}
+}
+
+func TestA2SProtocol_Query_RotatedChallenge(t *testing.T) {
+	// The server answers the first two challenged requests with a fresh challenge
+	server := newMockA2SServer(t, createA2SInfo("CS2 Server", "de_ancient", "cs2", "Counter-Strike 2", "1.0", 730, 4, 10))
+	server.setRequireChallenge(true)
+	server.setRotateChallenges(2)
+	server.setPlayers([]a2sPlayer{{name: "Player1", score: 5, duration: 60}})
+	defer server.Close()
+
+	protocol := &A2SProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "CS2 Server", info.Name)
+	assert.Len(t, info.Players.List, 1)
+	assert.NotContains(t, info.Extra, "player_list_unavailable")
+}
@@ -0,0 +1,183 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AssettoCorsaProtocol implements the Assetto Corsa dedicated server HTTP API
+type AssettoCorsaProtocol struct{}
+
+func init() {
+	registry.Register(&AssettoCorsaProtocol{})
+}
+
+func (a *AssettoCorsaProtocol) Name() string {
+	return "assetto-corsa"
+}
+
+func (a *AssettoCorsaProtocol) Network() string {
+	return "tcp"
+}
+
+func (a *AssettoCorsaProtocol) DefaultPort() int {
+	return 9600
+}
+
+func (a *AssettoCorsaProtocol) DefaultQueryPort() int {
+	return 8081
+}
+
+func (a *AssettoCorsaProtocol) Games() []GameConfig {
+	return []GameConfig{
+		{Name: "assetto-corsa", GamePort: 9600, QueryPort: 8081},
+	}
+}
+
+func (a *AssettoCorsaProtocol) DetectGame(info *ServerInfo) string {
+	return "assetto-corsa"
+}
+
+// assettoCorsaSessions maps the session type IDs reported by acServer to names
+var assettoCorsaSessions = map[int]string{
+	0: "booking",
+	1: "practice",
+	2: "qualify",
+	3: "race",
+}
+
+// AssettoCorsaInfo represents the acServer /INFO response
+type AssettoCorsaInfo struct {
+	Name       string   `json:"name"`
+	Port       int      `json:"port"`
+	Clients    int      `json:"clients"`
+	MaxClients int      `json:"maxclients"`
+	Track      string   `json:"track"`
+	Cars       []string `json:"cars"`
+	Session    int      `json:"session"`
+	Pass       bool     `json:"pass"`
+}
+
+// valid reports whether the response has the fields acServer always sends: a track, a
+// client limit and the list of allowed cars
+func (i *AssettoCorsaInfo) valid() bool {
+	return i.Track != "" && i.MaxClients > 0 && i.Cars != nil
+}
+
+// AssettoCorsaEntryList represents the acServer /JSON| response
+type AssettoCorsaEntryList struct {
+	Cars []struct {
+		Model       string `json:"Model"`
+		DriverName  string `json:"DriverName"`
+		IsConnected bool   `json:"IsConnected"`
+	} `json:"Cars"`
+}
+
+func (a *AssettoCorsaProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
+	if opts.Debug {
+		debugLogf(opts, "AssettoCorsa", "Starting query for %s", addr)
+	}
+
+	client := &http.Client{Timeout: getHTTPTimeout(opts), CheckRedirect: httpRedirectPolicy(opts)}
+	if opts.Dial != nil || opts.TLSConfig != nil {
+		client.Transport = &http.Transport{DialContext: opts.Dial, TLSClientConfig: opts.TLSConfig}
+	}
+
+	start := time.Now()
+	var acInfo AssettoCorsaInfo
+	if err := a.getJSON(ctx, client, addr, "/INFO", opts, &acInfo); err != nil {
+		if opts.Debug {
+			debugLogf(opts, "AssettoCorsa", "INFO request failed: %v", err)
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("info request failed: %w", err)
+	}
+	ping := int(math.Ceil(float64(time.Since(start).Nanoseconds()) / 1e6))
+
+	// Any JSON API decodes into the struct, so require the fields every acServer reports
+	if !acInfo.valid() {
+		if opts.Debug {
+			debugLog(opts, "AssettoCorsa", "INFO response lacks acServer fields")
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("not an Assetto Corsa server: missing track, maxclients or cars")
+	}
+
+	info := &ServerInfo{
+		Name:   acInfo.Name,
+		Map:    acInfo.Track,
+		Ping:   ping,
+		Online: true,
+		Players: PlayerInfo{
+			Current: acInfo.Clients,
+			Max:     acInfo.MaxClients,
+		},
+		Extra: map[string]string{
+			"cars":     strings.Join(acInfo.Cars, ","),
+			"password": strconv.FormatBool(acInfo.Pass),
+		},
+	}
+	if session, ok := assettoCorsaSessions[acInfo.Session]; ok {
+		info.Extra["session"] = session
+	}
+	if acInfo.Port > 0 {
+		info.Extra["game_port"] = strconv.Itoa(acInfo.Port)
+	}
+	info.Game = a.DetectGame(info)
+
+	if opts.Players {
+		if opts.Debug {
+			debugLog(opts, "AssettoCorsa", "Requesting entry list")
+		}
+		var entries AssettoCorsaEntryList
+		if err := a.getJSON(ctx, client, addr, "/JSON|", opts, &entries); err != nil {
+			if opts.Debug {
+				debugLogf(opts, "AssettoCorsa", "Entry list request failed: %v", err)
+			}
+			info.Players.List = []Player{}
+			info.Extra["player_list_unavailable"] = "true"
+		} else {
+			info.Players.List = make([]Player, 0, len(entries.Cars))
+			for _, car := range entries.Cars {
+				if car.IsConnected && car.DriverName != "" {
+					info.Players.List = append(info.Players.List, Player{Name: car.DriverName})
+				}
+			}
+		}
+	}
+
+	if opts.Debug {
+		debugLogf(opts, "AssettoCorsa", "Query completed: %s on %s (%d/%d)", info.Name, info.Map, info.Players.Current, info.Players.Max)
+	}
+	return info, nil
+}
+
+// getJSON requests path from the acServer HTTP API over the schemes from httpSchemes and
+// decodes at most the maximum stream size of the JSON body into v
+func (a *AssettoCorsaProtocol) getJSON(ctx context.Context, client *http.Client, addr, path string, opts *Options, v interface{}) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+
+	resp, err := getHTTP(ctx, client, opts, func(scheme string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", scheme+"://"+addr+"/", nil)
+		if err != nil {
+			return nil, err
+		}
+		// acServer expects paths such as /JSON| unescaped, so bypass URL path encoding
+		req.URL.Opaque = path
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(io.LimitReader(resp.Body, int64(getMaxStreamSize(opts)))).Decode(v)
+}
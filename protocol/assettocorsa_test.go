@@ -0,0 +1,189 @@
+package protocol
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAssettoCorsaHandler serves canned acServer /INFO and /JSON| responses
+var mockAssettoCorsaHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.RequestURI {
+	case "/INFO":
+		w.Write([]byte(`{"name":"Sunday League","port":9600,"clients":2,"maxclients":24,` +
+			`"track":"ks_nordschleife","cars":["ks_porsche_911_gt3_r","ks_audi_r8_plus"],"session":3,"pass":true}`))
+	case "/JSON|":
+		w.Write([]byte(`{"Cars":[{"Model":"ks_porsche_911_gt3_r","DriverName":"Alice","IsConnected":true},` +
+			`{"Model":"ks_audi_r8_plus","DriverName":"Bob","IsConnected":true},` +
+			`{"Model":"ks_audi_r8_plus","DriverName":"","IsConnected":false}]}`))
+	default:
+		http.NotFound(w, r)
+	}
+})
+
+// startMockAssettoCorsaServer serves mockAssettoCorsaHandler over plain HTTP and returns its address
+func startMockAssettoCorsaServer(t *testing.T) string {
+	server := httptest.NewServer(mockAssettoCorsaHandler)
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestAssettoCorsaProtocol_Query(t *testing.T) {
+	addr := startMockAssettoCorsaServer(t)
+
+	protocol := &AssettoCorsaProtocol{}
+	opts := &Options{Timeout: 5 * time.Second, Players: true}
+
+	info, err := protocol.Query(context.Background(), addr, opts)
+
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Equal(t, "Sunday League", info.Name)
+	assert.Equal(t, "assetto-corsa", info.Game)
+	assert.Equal(t, "ks_nordschleife", info.Map)
+	assert.Equal(t, 2, info.Players.Current)
+	assert.Equal(t, 24, info.Players.Max)
+	assert.Equal(t, "race", info.Extra["session"])
+	assert.Equal(t, "ks_porsche_911_gt3_r,ks_audi_r8_plus", info.Extra["cars"])
+	assert.Equal(t, "true", info.Extra["password"])
+	assert.Equal(t, "9600", info.Extra["game_port"])
+	assert.Equal(t, []Player{{Name: "Alice"}, {Name: "Bob"}}, info.Players.List)
+}
+
+func TestAssettoCorsaProtocol_Query_NotAssettoCorsa(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	protocol := &AssettoCorsaProtocol{}
+	opts := &Options{Timeout: 5 * time.Second}
+
+	info, err := protocol.Query(context.Background(), strings.TrimPrefix(server.URL, "http://"), opts)
+
+	assert.Error(t, err)
+	assert.False(t, info.Online)
+}
+
+func TestAssettoCorsaProtocol_Query_GenericJSON(t *testing.T) {
+	protocol := &AssettoCorsaProtocol{}
+
+	// Other JSON APIs answering /INFO are not acServer
+	for _, body := range []string{
+		`{}`,
+		`{"name":"Status API","version":"2.1.0","uptime":86400}`,
+		`{"name":"Half Match","track":"monza","maxclients":10}`,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+
+		info, err := protocol.Query(context.Background(), strings.TrimPrefix(server.URL, "http://"), &Options{Timeout: 2 * time.Second})
+		server.Close()
+
+		assert.Error(t, err, body)
+		assert.False(t, info.Online, body)
+	}
+}
+
+func TestAssettoCorsaProtocol_Query_HTTPTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"name":"Slow Server","clients":0,"maxclients":10,"track":"monza","cars":["ks_ferrari_488_gt3"]}`))
+	}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	protocol := &AssettoCorsaProtocol{}
+
+	// The main timeout alone is too short for the slow HTTP API
+	_, err := protocol.Query(context.Background(), addr, &Options{Timeout: 50 * time.Millisecond})
+	assert.Error(t, err)
+
+	// A separate HTTP timeout gives it enough time
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 50 * time.Millisecond, HTTPTimeout: 2 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, "Slow Server", info.Name)
+}
+
+func TestAssettoCorsaProtocol_Query_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(mockAssettoCorsaHandler)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	protocol := &AssettoCorsaProtocol{}
+
+	// The server rejects plain HTTP and its self-signed certificate fails default verification
+	_, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second})
+	assert.Error(t, err)
+
+	info, err := protocol.Query(context.Background(), addr, &Options{
+		Timeout:   2 * time.Second,
+		Players:   true,
+		TLSConfig: &tls.Config{InsecureSkipVerify: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Sunday League", info.Name)
+	assert.Len(t, info.Players.List, 2)
+}
+
+func TestGetHTTP_FallsBackToHTTPSWhenPlainHTTPRejected(t *testing.T) {
+	server := httptest.NewTLSServer(mockAssettoCorsaHandler)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	var schemes []string
+	resp, err := getHTTP(context.Background(), server.Client(), &Options{}, func(scheme string) (*http.Request, error) {
+		schemes = append(schemes, scheme)
+		return http.NewRequest("GET", scheme+"://"+addr+"/INFO", nil)
+	})
+
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"http", "https"}, schemes)
+}
+
+func TestGetHTTP_RefusedPortTriedOnce(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	var schemes []string
+	_, err = getHTTP(context.Background(), http.DefaultClient, &Options{}, func(scheme string) (*http.Request, error) {
+		schemes = append(schemes, scheme)
+		return http.NewRequest("GET", scheme+"://"+addr+"/INFO", nil)
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"http"}, schemes)
+}
+
+func TestAssettoCorsaProtocol_Query_Redirects(t *testing.T) {
+	target := startMockAssettoCorsaServer(t)
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+target+r.RequestURI, http.StatusFound)
+	}))
+	defer redirecting.Close()
+	addr := strings.TrimPrefix(redirecting.URL, "http://")
+
+	protocol := &AssettoCorsaProtocol{}
+
+	// Redirects are not followed by default
+	_, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second})
+	var statusErr *httpStatusError
+	assert.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusFound, statusErr.code)
+
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second, MaxRedirects: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Sunday League", info.Name)
+}
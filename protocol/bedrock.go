@@ -0,0 +1,231 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BedrockProtocol implements the RakNet unconnected ping answered by Minecraft Bedrock Edition servers
+type BedrockProtocol struct{}
+
+func init() {
+	registry.Register(&BedrockProtocol{})
+}
+
+func (b *BedrockProtocol) Name() string {
+	return "minecraft-bedrock"
+}
+
+func (b *BedrockProtocol) Network() string {
+	return "udp"
+}
+
+func (b *BedrockProtocol) DefaultPort() int {
+	return 19132
+}
+
+func (b *BedrockProtocol) DefaultQueryPort() int {
+	return 19132
+}
+
+func (b *BedrockProtocol) Games() []GameConfig {
+	return []GameConfig{
+		{Name: "minecraft-bedrock", GamePort: 19132, QueryPort: 19132},
+	}
+}
+
+func (b *BedrockProtocol) DetectGame(info *ServerInfo) string {
+	return "minecraft-bedrock"
+}
+
+// RakNet packet IDs
+const (
+	raknetUnconnectedPing = 0x01
+	raknetUnconnectedPong = 0x1C
+)
+
+// raknetMagic marks RakNet offline messages
+var raknetMagic = []byte{0x00, 0xFF, 0xFF, 0x00, 0xFE, 0xFE, 0xFE, 0xFE, 0xFD, 0xFD, 0xFD, 0xFD, 0x12, 0x34, 0x56, 0x78}
+
+// raknetClientGUID identifies our pings; servers echo only the timestamp
+const raknetClientGUID = 0x4753515279
+
+// Fields of the semicolon separated server ID string in an unconnected pong, e.g.
+// "MCPE;Dedicated Server;671;1.20.81;3;10;13253860892328930865;Bedrock level;Survival;1;19132;19133;"
+const (
+	bedrockFieldEdition = iota
+	bedrockFieldMOTD
+	bedrockFieldProtocol
+	bedrockFieldVersion
+	bedrockFieldPlayers
+	bedrockFieldMaxPlayers
+	bedrockFieldServerID
+	bedrockFieldLevelName
+	bedrockFieldGameMode
+	bedrockFieldGameModeID
+	bedrockFieldPortV4
+	bedrockFieldPortV6
+)
+
+func (b *BedrockProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
+	if opts.Debug {
+		debugLogf(opts, "Bedrock", "Starting query for %s", addr)
+	}
+
+	conn, err := setupConnection(ctx, "udp", addr, opts)
+	if err != nil {
+		return &ServerInfo{Online: false}, err
+	}
+	defer conn.Close()
+
+	pingStart := time.Now()
+	serverID, err := b.ping(conn)
+	ping := int(math.Ceil(float64(time.Since(pingStart).Nanoseconds()) / 1e6))
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "Bedrock", "Unconnected ping failed: %v", err)
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("unconnected ping failed: %w", err)
+	}
+	if opts.Debug {
+		debugLogf(opts, "Bedrock", "Received server ID %q (ping: %dms)", serverID, ping)
+	}
+
+	info := b.parseServerID(serverID)
+	info.Ping = ping
+	info.Game = b.DetectGame(info)
+
+	if opts.Players {
+		b.queryPlayers(ctx, addr, opts, info)
+	}
+
+	if opts.Debug {
+		debugLogf(opts, "Bedrock", "Query completed: %s (%d/%d)", info.Name, info.Players.Current, info.Players.Max)
+	}
+	return info, nil
+}
+
+// bedrockPlayersTimeout bounds the supplementary player query, which vanilla servers never answer
+const bedrockPlayersTimeout = DiscoveryTimeout * 3
+
+// queryPlayers fills the player list from the GameSpy4 query that PocketMine and Nukkit
+// answer on the RakNet port when enable-query is set. The ping doesn't say whether the
+// query is enabled, so it is always tried and a failure only marks the list unavailable.
+func (b *BedrockProtocol) queryPlayers(ctx context.Context, addr string, opts *Options, info *ServerInfo) {
+	queryOpts := *opts
+	queryOpts.Timings = nil
+	if queryOpts.Timeout <= 0 || queryOpts.Timeout > bedrockPlayersTimeout {
+		queryOpts.Timeout = bedrockPlayersTimeout
+	}
+
+	stat, err := (&MinecraftQueryProtocol{}).Query(ctx, addr, &queryOpts)
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "Bedrock", "Player query failed, list unavailable: %v", err)
+		}
+		info.Extra["player_list_unavailable"] = "true"
+		return
+	}
+	info.Players.List = stat.Players.List
+}
+
+// Probe sends an unconnected ping and accepts any valid pong
+func (b *BedrockProtocol) Probe(ctx context.Context, addr string, opts *Options) error {
+	conn, err := setupConnection(ctx, "udp", addr, opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := b.ping(conn); err != nil {
+		return fmt.Errorf("unconnected ping failed: %w", err)
+	}
+	return nil
+}
+
+// ping sends a RakNet unconnected ping and returns the server ID string from the pong
+func (b *BedrockProtocol) ping(conn net.Conn) (string, error) {
+	var request bytes.Buffer
+	request.WriteByte(raknetUnconnectedPing)
+	binary.Write(&request, binary.BigEndian, time.Now().UnixMilli())
+	request.Write(raknetMagic)
+	binary.Write(&request, binary.BigEndian, uint64(raknetClientGUID))
+	if _, err := conn.Write(request.Bytes()); err != nil {
+		return "", err
+	}
+
+	response := make([]byte, 1500)
+	n, err := conn.Read(response)
+	if err != nil {
+		return "", err
+	}
+	return b.parsePong(response[:n])
+}
+
+// parsePong validates an unconnected pong (ID, timestamp, server GUID, magic, length
+// prefixed server ID) and returns the server ID. A length running past the packet is
+// clamped to the bytes received, since some servers miscount multi-byte MOTDs.
+func (b *BedrockProtocol) parsePong(data []byte) (string, error) {
+	const headerSize = 1 + 8 + 8 + 16 + 2
+	if len(data) < headerSize || data[0] != raknetUnconnectedPong {
+		return "", fmt.Errorf("invalid unconnected pong")
+	}
+	if !bytes.Equal(data[17:33], raknetMagic) {
+		return "", fmt.Errorf("invalid RakNet magic")
+	}
+
+	body := data[headerSize:]
+	if length := int(binary.BigEndian.Uint16(data[33:35])); length < len(body) {
+		body = body[:length]
+	}
+	return string(body), nil
+}
+
+// parseServerID reads the server ID string by field index. Server software disagrees on
+// the field count (PocketMine, Nukkit and vanilla each omit or add trailing fields), so
+// missing or malformed fields are left empty rather than failing the query.
+func (b *BedrockProtocol) parseServerID(serverID string) *ServerInfo {
+	fields := strings.Split(serverID, ";")
+	field := func(i int) string {
+		if i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	info := &ServerInfo{
+		Version: field(bedrockFieldVersion),
+		Map:     field(bedrockFieldLevelName),
+		Online:  true,
+		Extra:   make(map[string]string),
+	}
+	setCleanName(info, "minecraft-bedrock", field(bedrockFieldMOTD))
+	info.Players.Current, _ = strconv.Atoi(field(bedrockFieldPlayers))
+	info.Players.Max, _ = strconv.Atoi(field(bedrockFieldMaxPlayers))
+
+	for key, index := range map[string]int{
+		"edition":          bedrockFieldEdition,
+		"protocol_version": bedrockFieldProtocol,
+		"server_id":        bedrockFieldServerID,
+		"game_mode":        bedrockFieldGameMode,
+	} {
+		if value := field(index); value != "" {
+			info.Extra[key] = value
+		}
+	}
+	if port, err := strconv.Atoi(field(bedrockFieldPortV4)); err == nil && port > 0 {
+		info.Extra["game_port"] = strconv.Itoa(port)
+	}
+	if port, err := strconv.Atoi(field(bedrockFieldPortV6)); err == nil && port > 0 {
+		info.Extra["game_port_v6"] = strconv.Itoa(port)
+	}
+
+	return info
+}
@@ -0,0 +1,202 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildBedrockPong builds an unconnected pong echoing the ping's timestamp.
+func buildBedrockPong(ping []byte, serverID string, length int) []byte {
+	var response bytes.Buffer
+	response.WriteByte(0x1C)
+	response.Write(ping[1:9])                                             // Timestamp
+	binary.Write(&response, binary.BigEndian, uint64(0x1122334455667788)) // Server GUID
+	response.Write(raknetMagic)
+	binary.Write(&response, binary.BigEndian, uint16(length))
+	response.WriteString(serverID)
+	return response.Bytes()
+}
+
+// startMockBedrockServer answers unconnected pings with the given server ID string.
+func startMockBedrockServer(t *testing.T, serverID string) string {
+	return startMockBedrockServerWithLength(t, serverID, len(serverID))
+}
+
+// startMockBedrockServerWithLength is startMockBedrockServer with an explicit length prefix.
+func startMockBedrockServerWithLength(t *testing.T, serverID string, length int) string {
+	return startMockBedrock(t, serverID, length, nil)
+}
+
+// startMockBedrockServerWithQuery also answers the GameSpy4 query with the given players,
+// as PocketMine and Nukkit do with enable-query set.
+func startMockBedrockServerWithQuery(t *testing.T, serverID string, players []string) string {
+	return startMockBedrock(t, serverID, len(serverID), players)
+}
+
+// startMockBedrock answers unconnected pings, and GameSpy4 queries when players is non-nil.
+func startMockBedrock(t *testing.T, serverID string, length int, players []string) string {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, addr, err := l.ReadFrom(buffer)
+			if err != nil {
+				return // Listener closed
+			}
+			if players != nil {
+				if response := mockMinecraftQueryResponse(buffer[:n], [][2]string{{"hostname", "Bedrock"}}, players); response != nil {
+					l.WriteTo(response, addr)
+					continue
+				}
+			}
+			if n < 33 || buffer[0] != 0x01 || !bytes.Equal(buffer[9:25], raknetMagic) {
+				continue
+			}
+			l.WriteTo(buildBedrockPong(buffer[:n], serverID, length), addr)
+		}
+	}()
+
+	return l.LocalAddr().String()
+}
+
+func TestBedrockProtocol_Query(t *testing.T) {
+	addr := startMockBedrockServer(t, "MCPE;Dedicated Server;671;1.20.81;3;10;13253860892328930865;Bedrock level;Survival;1;19132;19133;")
+
+	protocol := &BedrockProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Equal(t, "minecraft-bedrock", info.Game)
+	assert.Equal(t, "Dedicated Server", info.Name)
+	assert.Equal(t, "1.20.81", info.Version)
+	assert.Equal(t, "Bedrock level", info.Map)
+	assert.Equal(t, 3, info.Players.Current)
+	assert.Equal(t, 10, info.Players.Max)
+	assert.Equal(t, map[string]string{
+		"edition":          "MCPE",
+		"protocol_version": "671",
+		"server_id":        "13253860892328930865",
+		"game_mode":        "Survival",
+		"game_port":        "19132",
+		"game_port_v6":     "19133",
+	}, info.Extra)
+}
+
+func TestBedrockProtocol_Query_FormattedMOTD(t *testing.T) {
+	addr := startMockBedrockServer(t, "MCPE;§aGreen §lServer;671;1.20.81;3;10")
+
+	protocol := &BedrockProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Green Server", info.Name)
+	assert.Equal(t, "§aGreen §lServer", info.Extra["name_raw"])
+}
+
+func TestBedrockProtocol_Query_ServerIDVariants(t *testing.T) {
+	tests := []struct {
+		name       string
+		serverID   string
+		motd       string
+		version    string
+		players    int
+		maxPlayers int
+		gamePort   string
+	}{
+		{"pocketmine six fields", "MCPE;PocketMine-MP Server;589;1.20.0;0;20", "PocketMine-MP Server", "1.20.0", 0, 20, ""},
+		{"nukkit without ports", "MCPE;Nukkit Server;649;1.20.60;5;50;1;Nukkit;Survival", "Nukkit Server", "1.20.60", 5, 50, ""},
+		{"education edition", "MCEE;Classroom;557;1.19.51;2;30;42;world;Creative;1;19132;19133;", "Classroom", "1.19.51", 2, 30, "19132"},
+		{"malformed counts", "MCPE;Odd Server;671;1.20.81;many;lots;1;world", "Odd Server", "1.20.81", 0, 0, ""},
+		{"edition and motd only", "MCPE;Bare", "Bare", "", 0, 0, ""},
+		{"empty", "", "", "", 0, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := startMockBedrockServer(t, tt.serverID)
+
+			protocol := &BedrockProtocol{}
+			info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second})
+
+			assert.NoError(t, err)
+			assert.True(t, info.Online)
+			assert.Equal(t, tt.motd, info.Name)
+			assert.Equal(t, tt.version, info.Version)
+			assert.Equal(t, tt.players, info.Players.Current)
+			assert.Equal(t, tt.maxPlayers, info.Players.Max)
+			assert.Equal(t, tt.gamePort, info.Extra["game_port"])
+		})
+	}
+}
+
+func TestBedrockProtocol_Query_LengthPastPacket(t *testing.T) {
+	// The length prefix claims more bytes than were sent
+	addr := startMockBedrockServerWithLength(t, "MCPE;Short Server;671;1.20.81;1;10", 500)
+
+	protocol := &BedrockProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Short Server", info.Name)
+	assert.Equal(t, 10, info.Players.Max)
+}
+
+func TestBedrockProtocol_ParsePong_Invalid(t *testing.T) {
+	protocol := &BedrockProtocol{}
+	ping := make([]byte, 33)
+
+	_, err := protocol.parsePong([]byte{0x1C, 0x00})
+	assert.Error(t, err)
+
+	pong := buildBedrockPong(ping, "MCPE;Server", 11)
+	pong[21] = 0x00 // Corrupt the magic
+	_, err = protocol.parsePong(pong)
+	assert.Error(t, err)
+
+	pong = buildBedrockPong(ping, "MCPE;Server", 11)
+	pong[0] = 0x1D
+	_, err = protocol.parsePong(pong)
+	assert.Error(t, err)
+}
+
+func TestBedrockProtocol_Probe(t *testing.T) {
+	addr := startMockBedrockServer(t, "MCPE;Probe Server;671;1.20.81;0;10")
+
+	protocol := &BedrockProtocol{}
+	assert.NoError(t, protocol.Probe(context.Background(), addr, &Options{Timeout: 5 * time.Second}))
+}
+
+func TestBedrockProtocol_Query_Players(t *testing.T) {
+	serverID := "MCPE;PocketMine-MP Server;589;1.20.0;2;20"
+
+	// PocketMine with enable-query lists the players
+	addr := startMockBedrockServerWithQuery(t, serverID, []string{"Steve", "Alex"})
+	protocol := &BedrockProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Player{{Name: "Steve"}, {Name: "Alex"}}, info.Players.List)
+	assert.NotContains(t, info.Extra, "player_list_unavailable")
+
+	// Vanilla servers only answer the ping
+	addr = startMockBedrockServer(t, serverID)
+	info, err = protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, info.Players.Current)
+	assert.Equal(t, 20, info.Players.Max)
+	assert.Empty(t, info.Players.List)
+	assert.Equal(t, "true", info.Extra["player_list_unavailable"])
+}
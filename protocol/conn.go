@@ -0,0 +1,44 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+// QueryConn queries a server over an already established connection instead of dialing,
+// for tunnels or tests. The connection is used for the protocol's main exchange only:
+// follow-up connections such as parallel sub-queries fail, and conn is left open for the caller.
+func QueryConn(ctx context.Context, proto Protocol, conn net.Conn, opts *Options) (*ServerInfo, error) {
+	connOpts := *opts
+	connOpts.ParallelQueries = false
+	connOpts.LookupHost = nil
+
+	var once sync.Once
+	connOpts.Dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var provided net.Conn
+		once.Do(func() { provided = &borrowedConn{Conn: conn} })
+		if provided == nil {
+			return nil, errors.New("provided connection already in use")
+		}
+		return provided, nil
+	}
+
+	// Protocols expect host:port, which connections such as net.Pipe don't provide
+	addr := conn.RemoteAddr().String()
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "0")
+	}
+
+	return proto.Query(ctx, addr, &connOpts)
+}
+
+// borrowedConn keeps a caller owned connection open when a protocol closes it
+type borrowedConn struct {
+	net.Conn
+}
+
+func (c *borrowedConn) Close() error {
+	return nil
+}
@@ -0,0 +1,78 @@
+package protocol
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryConn_Pipe(t *testing.T) {
+	// 1. Serve a Minecraft status response over an in-memory pipe
+	server := &mockMinecraftServer{t: t, response: createMinecraftStatus("", "1.20.4", 765, 4, 20, "Piped Server")}
+	client, serverConn := net.Pipe()
+	defer client.Close()
+	go server.handleRequest(serverConn)
+
+	// 2. Query over the pipe without dialing
+	info, err := QueryConn(context.Background(), &MinecraftProtocol{}, client, &Options{Timeout: 5 * time.Second})
+
+	// 3. Assert the parsed result
+	assert.NoError(t, err)
+	assert.Equal(t, "Piped Server", info.Name)
+	assert.Equal(t, 4, info.Players.Current)
+}
+
+func TestQueryConn_UDP(t *testing.T) {
+	server := newMockA2SServer(t, createA2SInfo("Tunnelled Server", "de_dust2", "csgo", "Counter-Strike", "1.0", 730, 3, 10))
+	defer server.Close()
+
+	conn, err := net.Dial("udp", server.Addr())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	info, err := QueryConn(context.Background(), &A2SProtocol{}, conn, &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Tunnelled Server", info.Name)
+
+	// The caller still owns the connection
+	_, err = conn.Write([]byte{0xFF})
+	assert.NoError(t, err)
+}
+
+func TestQueryConn_TerrariaDoesNotDialTShock(t *testing.T) {
+	// A TShock API on the same host must not be reached past the provided connection
+	requests := startMockTShockServer(t, 0)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		request := make([]byte, 5)
+		if _, err := io.ReadFull(conn, request); err == nil {
+			conn.Write([]byte{0x05, 0x00, 0x00, 0x00, 0x13}) // Player info packet
+		}
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	info, err := QueryConn(context.Background(), &TerrariaProtocol{}, conn, &Options{Timeout: 2 * time.Second})
+
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Equal(t, "terraria", info.Game)
+	assert.Equal(t, int32(0), requests.Load())
+}
@@ -0,0 +1,50 @@
+package protocol
+
+import (
+	"net"
+	"strconv"
+)
+
+// steamConnectGames are the Source engine games that accept steam://connect links
+var steamConnectGames = map[string]bool{
+	"counter-strike-2": true,
+	"counter-strike":   true,
+	"counter-source":   true,
+	"garrys-mod":       true,
+	"team-fortress-2":  true,
+	"left-4-dead":      true,
+	"left-4-dead-2":    true,
+	"half-life":        true,
+	"insurgency":       true,
+	"day-of-defeat":    true,
+}
+
+// ConnectString returns a hint for joining the server on its game port: a steam://connect
+// link for Source games, a console command for Rust and a plain host:port otherwise
+func (info *ServerInfo) ConnectString() string {
+	hostPort := net.JoinHostPort(info.Address, strconv.Itoa(info.GamePort()))
+
+	switch {
+	case steamConnectGames[info.Game]:
+		return "steam://connect/" + hostPort
+	case info.Game == "rust":
+		return "client.connect " + hostPort
+	default:
+		return hostPort
+	}
+}
+
+// GamePort returns the port players connect to. Port holds a game port reported by the
+// server when queried through the query package and otherwise the port that was queried, so
+// this prefers a reported game port and otherwise maps a default query port back to the
+// game's default game port.
+func (info *ServerInfo) GamePort() int {
+	if port, err := strconv.Atoi(info.Extra["game_port"]); err == nil && port > 0 {
+		return port
+	}
+	if config, _, exists := registry.GetGameConfig(info.Game); exists &&
+		config.GamePort != config.QueryPort && info.Port == config.QueryPort {
+		return config.GamePort
+	}
+	return info.Port
+}
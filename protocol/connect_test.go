@@ -0,0 +1,29 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerInfo_ConnectString(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     ServerInfo
+		expected string
+	}{
+		{"source game", ServerInfo{Game: "team-fortress-2", Address: "10.0.0.1", Port: 27015}, "steam://connect/10.0.0.1:27015"},
+		{"rust console command", ServerInfo{Game: "rust", Address: "10.0.0.1", Port: 28015}, "client.connect 10.0.0.1:28015"},
+		{"minecraft", ServerInfo{Game: "minecraft", Address: "mc.example.com", Port: 25565}, "mc.example.com:25565"},
+		{"default query port maps to game port", ServerInfo{Game: "valheim", Address: "10.0.0.1", Port: 2457}, "10.0.0.1:2456"},
+		{"custom query port is kept", ServerInfo{Game: "valheim", Address: "10.0.0.1", Port: 3457}, "10.0.0.1:3457"},
+		{"reported game port", ServerInfo{Game: "assetto-corsa", Address: "10.0.0.1", Port: 8081, Extra: map[string]string{"game_port": "9601"}}, "10.0.0.1:9601"},
+		{"IPv6", ServerInfo{Game: "minecraft", Address: "::1", Port: 25565}, "[::1]:25565"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.info.ConnectString())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FlatServerInfo is a flat view of ServerInfo using the field names common in
+// server browser frontends (map_name, num_players, max_players)
+type FlatServerInfo struct {
+	Name        string            `json:"name"`
+	Game        string            `json:"game"`
+	Version     string            `json:"version"`
+	Address     string            `json:"address"`
+	Port        int               `json:"port"`
+	QueryPort   int               `json:"query_port"`
+	MapName     string            `json:"map_name"`
+	Region      string            `json:"region,omitempty"`
+	Language    string            `json:"language,omitempty"`
+	NumPlayers  int               `json:"num_players"`
+	MaxPlayers  int               `json:"max_players"`
+	PlayerNames []string          `json:"player_names,omitempty"`
+	Ping        int               `json:"ping"`
+	Online      bool              `json:"online"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+// Flat returns the flat view of the server info
+func (info *ServerInfo) Flat() FlatServerInfo {
+	flat := FlatServerInfo{
+		Name:       info.Name,
+		Game:       info.Game,
+		Version:    info.Version,
+		Address:    info.Address,
+		Port:       info.Port,
+		QueryPort:  info.QueryPort,
+		MapName:    info.Map,
+		Region:     info.Region,
+		Language:   info.Language,
+		NumPlayers: info.Players.Current,
+		MaxPlayers: info.Players.Max,
+		Ping:       info.Ping,
+		Online:     info.Online,
+		Extra:      info.Extra,
+	}
+	for _, player := range info.Players.List {
+		flat.PlayerNames = append(flat.PlayerNames, player.Name)
+	}
+	return flat
+}
+
+// MarshalFlat encodes the server info as flat JSON without changing the canonical ServerInfo encoding
+func (info *ServerInfo) MarshalFlat() ([]byte, error) {
+	return json.Marshal(info.Flat())
+}
+
+// MarshalSubset encodes only the named top-level fields of info, using their JSON names
+// (e.g. "name", "players"). Fields left out by omitempty stay out, as in the canonical
+// encoding. An unknown name is an error rather than being silently dropped.
+func MarshalSubset(info *ServerInfo, fields []string) ([]byte, error) {
+	known := serverInfoJSONFields()
+	for _, field := range fields {
+		if !known[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	subset := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, exists := all[field]; exists {
+			subset[field] = value
+		}
+	}
+	return json.Marshal(subset)
+}
+
+// serverInfoJSONFields returns the JSON names of the ServerInfo fields
+func serverInfoJSONFields() map[string]bool {
+	t := reflect.TypeOf(ServerInfo{})
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
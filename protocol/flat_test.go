@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerInfo_MarshalFlat(t *testing.T) {
+	info := &ServerInfo{
+		Name:      "Flat Server",
+		Game:      "rust",
+		Version:   "2024",
+		Address:   "10.0.0.1",
+		Port:      28015,
+		QueryPort: 28015,
+		Map:       "Procedural Map",
+		Players: PlayerInfo{
+			Current: 2,
+			Max:     100,
+			List:    []Player{{Name: "Alice"}, {Name: "Bob"}},
+		},
+		Ping:   12,
+		Online: true,
+	}
+
+	data, err := info.MarshalFlat()
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "Flat Server",
+		"game": "rust",
+		"version": "2024",
+		"address": "10.0.0.1",
+		"port": 28015,
+		"query_port": 28015,
+		"map_name": "Procedural Map",
+		"num_players": 2,
+		"max_players": 100,
+		"player_names": ["Alice", "Bob"],
+		"ping": 12,
+		"online": true
+	}`, string(data))
+}
+
+func TestMarshalSubset(t *testing.T) {
+	info := &ServerInfo{
+		Name:    "Subset Server",
+		Game:    "rust",
+		Players: PlayerInfo{Current: 2, Max: 100},
+		Ping:    12,
+		Online:  true,
+	}
+
+	data, err := MarshalSubset(info, []string{"name", "players", "online", "map"})
+
+	// map is empty and omitted, as in the full encoding
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "Subset Server",
+		"players": {"current": 2, "max": 100},
+		"online": true
+	}`, string(data))
+
+	_, err = MarshalSubset(info, []string{"name", "hostname"})
+	assert.ErrorContains(t, err, "hostname")
+}
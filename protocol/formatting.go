@@ -0,0 +1,43 @@
+package protocol
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// minecraftFormatCodes matches § formatting codes, which only Minecraft uses
+	minecraftFormatCodes = regexp.MustCompile(`§[0-9a-fk-orA-FK-OR]`)
+
+	// genericFormatCodes matches Quake style ^N and ^xRGB codes, Unity rich text tags
+	// used by Rust and similar games, bracketed hex colors and raw control characters
+	genericFormatCodes = regexp.MustCompile(
+		`\^x[0-9a-fA-F]{3}|\^[0-9]` +
+			`|</?(?:color|b|i|size)(?:=[^>]*)?>` +
+			`|\{#[0-9a-fA-F]{6}\}|\[[0-9a-fA-F]{6}\]` +
+			`|[\x01-\x08\x0B-\x1F]`)
+)
+
+// StripFormatting removes the color and formatting codes used by the named protocol from
+// s: § codes for the Minecraft protocols and the generic codes for everything else
+func StripFormatting(protocolName, s string) string {
+	switch protocolName {
+	case "minecraft", "minecraft-query", "minecraft-bedrock":
+		return minecraftFormatCodes.ReplaceAllString(s, "")
+	}
+	return genericFormatCodes.ReplaceAllString(s, "")
+}
+
+// setCleanName sets info.Name to name without formatting codes or surrounding space. When
+// that changes the name, the original is kept in info.Extra["name_raw"] so consumers that
+// render the codes still can.
+func setCleanName(info *ServerInfo, protocolName, name string) {
+	info.Name = strings.TrimSpace(StripFormatting(protocolName, name))
+	if info.Name == name {
+		return
+	}
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["name_raw"] = name
+}
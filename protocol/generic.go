@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// GenericUDPProtocol queries a UDP game the library has no protocol for, by sending a fixed
+// payload and handing the first response datagram to a caller-supplied parser
+type GenericUDPProtocol struct {
+	name    string
+	payload []byte
+	parse   func([]byte) (*ServerInfo, error)
+	port    int
+}
+
+// NewGenericUDP returns a protocol named name that sends payload to the query port (port by
+// default) and builds the ServerInfo with parse. parse should return an error for responses
+// that don't come from the game, since auto-detection treats any success as a match. Pass the
+// result to Register to make it available by name.
+func NewGenericUDP(name string, payload []byte, parse func([]byte) (*ServerInfo, error), port int) *GenericUDPProtocol {
+	return &GenericUDPProtocol{name: name, payload: payload, parse: parse, port: port}
+}
+
+func (g *GenericUDPProtocol) Name() string {
+	return g.name
+}
+
+func (g *GenericUDPProtocol) Network() string {
+	return "udp"
+}
+
+func (g *GenericUDPProtocol) DefaultPort() int {
+	return g.port
+}
+
+func (g *GenericUDPProtocol) DefaultQueryPort() int {
+	return g.port
+}
+
+func (g *GenericUDPProtocol) Games() []GameConfig {
+	return []GameConfig{
+		{Name: g.name, GamePort: g.port, QueryPort: g.port},
+	}
+}
+
+func (g *GenericUDPProtocol) DetectGame(info *ServerInfo) string {
+	return g.name
+}
+
+func (g *GenericUDPProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
+	if opts.Debug {
+		debugLogf(opts, g.name, "Starting generic UDP query for %s", addr)
+	}
+
+	start := time.Now()
+	response, err := RawExchange(ctx, "udp", addr, g.payload, opts)
+	ping := int(math.Ceil(float64(time.Since(start).Nanoseconds()) / 1e6))
+	if err != nil {
+		return &ServerInfo{Online: false}, err
+	}
+
+	info, err := g.parse(response)
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, g.name, "Parsing %d byte response failed: %v", len(response), err)
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("parse failed: %w", err)
+	}
+	if info == nil {
+		return &ServerInfo{Online: false}, fmt.Errorf("parse failed: no server info")
+	}
+
+	info.Online = true
+	if info.Game == "" {
+		info.Game = g.DetectGame(info)
+	}
+	if info.Ping == 0 {
+		info.Ping = ping
+	}
+	return info, nil
+}
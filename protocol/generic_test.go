@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startMockGenericServer answers "STATUS" datagrams with "OK;<name>;<players>"
+func startMockGenericServer(t *testing.T, name string, players int) string {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, addr, err := l.ReadFrom(buffer)
+			if err != nil {
+				return // Listener closed
+			}
+			if bytes.Equal(buffer[:n], []byte("STATUS")) {
+				l.WriteTo([]byte(fmt.Sprintf("OK;%s;%d", name, players)), addr)
+			}
+		}
+	}()
+
+	return l.LocalAddr().String()
+}
+
+// parseMockStatus parses the responses of startMockGenericServer
+func parseMockStatus(data []byte) (*ServerInfo, error) {
+	fields := strings.Split(string(data), ";")
+	if len(fields) != 3 || fields[0] != "OK" {
+		return nil, fmt.Errorf("unexpected response")
+	}
+	players, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	info := &ServerInfo{Name: fields[1]}
+	info.Players.Current = players
+	return info, nil
+}
+
+func TestGenericUDPProtocol_Query(t *testing.T) {
+	addr := startMockGenericServer(t, "Niche Server", 4)
+
+	protocol := NewGenericUDP("niche-game", []byte("STATUS"), parseMockStatus, 9999)
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second})
+
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Equal(t, "niche-game", info.Game)
+	assert.Equal(t, "Niche Server", info.Name)
+	assert.Equal(t, 4, info.Players.Current)
+
+	// A response the parser rejects is not a match
+	rejecting := NewGenericUDP("niche-game", []byte("STATUS"), func([]byte) (*ServerInfo, error) {
+		return nil, fmt.Errorf("not this game")
+	}, 9999)
+	_, err = rejecting.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second})
+	assert.ErrorContains(t, err, "not this game")
+}
+
+func TestRegister_GenericUDP(t *testing.T) {
+	protocol := NewGenericUDP("niche-game", []byte("STATUS"), parseMockStatus, 9999)
+	Register(protocol)
+	t.Cleanup(func() { Unregister("niche-game") })
+
+	registered, exists := GetProtocol("niche-game")
+	assert.True(t, exists)
+	assert.Same(t, protocol, registered)
+
+	config, _, exists := GetGameConfigFromRegistry("niche-game")
+	assert.True(t, exists)
+	assert.Equal(t, 9999, config.QueryPort)
+
+	Unregister("niche-game")
+	_, exists = GetProtocol("niche-game")
+	assert.False(t, exists)
+}
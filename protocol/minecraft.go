@@ -3,14 +3,14 @@ package protocol
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"net"
-	"regexp"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -25,6 +25,10 @@ func (m *MinecraftProtocol) Name() string {
 	return "minecraft"
 }
 
+func (m *MinecraftProtocol) Network() string {
+	return "tcp"
+}
+
 func (m *MinecraftProtocol) DefaultPort() int {
 	return 25565
 }
@@ -45,9 +49,33 @@ func (m *MinecraftProtocol) DetectGame(info *ServerInfo) string {
 
 func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
 	if opts.Debug {
-		debugLogf("Minecraft", "Starting query for %s", addr)
+		debugLogf(opts, "Minecraft", "Starting query for %s", addr)
 	}
-	
+
+	// Hardened servers delay or drop rapid status pings, so retry failures after the
+	// handshake with exponential backoff
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		info, err := m.queryStatus(ctx, addr, opts)
+		var retryable *retryableError
+		if err == nil || attempt >= opts.Retries || !errors.As(err, &retryable) {
+			return info, err
+		}
+
+		if opts.Debug {
+			debugLogf(opts, "Minecraft", "Attempt %d failed: %v, retrying in %v", attempt+1, err, backoff)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return info, err
+		}
+		backoff *= 2
+	}
+}
+
+// queryStatus performs a single handshake and status exchange
+func (m *MinecraftProtocol) queryStatus(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
 	conn, err := setupConnection(ctx, "tcp", addr, opts)
 	if err != nil {
 		return &ServerInfo{Online: false}, err
@@ -58,7 +86,7 @@ func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Option
 	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
 		if opts.Debug {
-			debugLogf("Minecraft", "Address parsing failed: %v", err)
+			debugLogf(opts, "Minecraft", "Address parsing failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("invalid address: %w", err)
 	}
@@ -66,58 +94,70 @@ func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Option
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
 		if opts.Debug {
-			debugLogf("Minecraft", "Port parsing failed: %v", err)
+			debugLogf(opts, "Minecraft", "Port parsing failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("invalid port: %w", err)
 	}
 	
 	if opts.Debug {
-		debugLogf("Minecraft", "Parsed address - host: %s, port: %d", host, port)
+		debugLogf(opts, "Minecraft", "Parsed address - host: %s, port: %d", host, port)
 	}
 
 	// Send handshake packet
 	if opts.Debug {
-		debugLog("Minecraft", "Sending handshake packet")
+		debugLog(opts, "Minecraft", "Sending handshake packet")
 	}
 	if err := m.sendHandshake(conn, host, port); err != nil {
 		if opts.Debug {
-			debugLogf("Minecraft", "Handshake failed: %v", err)
+			debugLogf(opts, "Minecraft", "Handshake failed: %v", err)
 		}
-		return &ServerInfo{Online: false}, fmt.Errorf("handshake failed: %w", err)
+		return &ServerInfo{Online: false}, &retryableError{fmt.Errorf("handshake failed: %w", err)}
 	}
 
 	// Send status request and measure ping
 	if opts.Debug {
-		debugLog("Minecraft", "Sending status request")
+		debugLog(opts, "Minecraft", "Sending status request")
 	}
 	pingStart := time.Now()
 	if err := m.sendStatusRequest(conn); err != nil {
 		if opts.Debug {
-			debugLogf("Minecraft", "Status request failed: %v", err)
+			debugLogf(opts, "Minecraft", "Status request failed: %v", err)
 		}
-		return &ServerInfo{Online: false}, fmt.Errorf("status request failed: %w", err)
+		return &ServerInfo{Online: false}, &retryableError{fmt.Errorf("status request failed: %w", err)}
 	}
 
 	// Read response
 	if opts.Debug {
-		debugLog("Minecraft", "Reading server response")
+		debugLog(opts, "Minecraft", "Reading server response")
+	}
+	// Large responses (big favicons, proxy networks) can take longer than one timeout to
+	// arrive, so each chunk gets its own timeout, within an overall limit so a server
+	// trickling bytes can't hold the query for long
+	timeout := getTimeout(opts)
+	deadline := &chunkDeadline{conn: conn, idle: timeout, limit: pingStart.Add(minecraftTransferTimeouts * timeout)}
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline.limit) {
+		deadline.limit = ctxDeadline
 	}
-	responseData, err := m.readVarIntPrefixedData(conn)
+	responseData, err := m.readVarIntPrefixedData(conn, getMaxStreamSize(opts), deadline.extend)
 	pingDuration := time.Since(pingStart)
 	ping := int(math.Ceil(float64(pingDuration.Nanoseconds()) / 1e6))
 	
 	if opts.Debug {
-		debugLogf("Minecraft", "Ping calculation: %v -> %dms", pingDuration, ping)
+		debugLogf(opts, "Minecraft", "Ping calculation: %v -> %dms", pingDuration, ping)
 	}
 	if err != nil {
 		if opts.Debug {
-			debugLogf("Minecraft", "Response read failed: %v", err)
+			debugLogf(opts, "Minecraft", "Response read failed: %v", err)
+		}
+		// A hostile length will be the same on retry
+		if errors.Is(err, errResponseTooLarge) {
+			return &ServerInfo{Online: false}, fmt.Errorf("read response failed: %w", err)
 		}
-		return &ServerInfo{Online: false}, fmt.Errorf("read response failed: %w", err)
+		return &ServerInfo{Online: false}, &retryableError{fmt.Errorf("read response failed: %w", err)}
 	}
 	
 	if opts.Debug {
-		debugLogf("Minecraft", "Received %d bytes of response data", len(responseData))
+		debugLogf(opts, "Minecraft", "Received %d bytes of response data", len(responseData))
 	}
 
 	// Skip packet ID
@@ -131,6 +171,10 @@ func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Option
 	if err != nil {
 		return &ServerInfo{Online: false}, fmt.Errorf("read JSON length failed: %w", err)
 	}
+	// The JSON must fit in the packet already read, so never allocate more than that
+	if jsonLength < 0 || jsonLength > reader.Len() {
+		return &ServerInfo{Online: false}, fmt.Errorf("invalid JSON length %d: %d bytes remain in packet", jsonLength, reader.Len())
+	}
 	
 	jsonData := make([]byte, jsonLength)
 	if _, err := io.ReadFull(reader, jsonData); err != nil {
@@ -139,26 +183,25 @@ func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Option
 
 	// Parse JSON response
 	if opts.Debug {
-		debugLogf("Minecraft", "Parsing JSON response (%d bytes)", len(jsonData))
+		debugLogf(opts, "Minecraft", "Parsing JSON response (%d bytes)", len(jsonData))
 	}
 	var status MinecraftStatus
 	if err := json.Unmarshal(jsonData, &status); err != nil {
 		if opts.Debug {
-			debugLogf("Minecraft", "JSON parsing failed: %v", err)
-			debugLogf("Minecraft", "Raw JSON data: %s", string(jsonData))
+			debugLogf(opts, "Minecraft", "JSON parsing failed: %v", err)
+			debugLogf(opts, "Minecraft", "Raw JSON data: %s", string(jsonData))
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	motd := m.cleanMotd(status.Description)
+	motd := m.motdText(status.Description)
 	
 	if opts.Debug {
-		debugLogf("Minecraft", "Parsed server info - MOTD: '%s', Version: '%s', Players: %d/%d", 
+		debugLogf(opts, "Minecraft", "Parsed server info - MOTD: '%s', Version: '%s', Players: %d/%d", 
 			motd, status.Version.Name, status.Players.Online, status.Players.Max)
 	}
 	
 	info := &ServerInfo{
-		Name:    motd, // Use MOTD as the server name for Minecraft
 		Version: status.Version.Name,
 		Online:  true,
 		Ping:    ping,
@@ -168,14 +211,33 @@ func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Option
 		},
 	}
 	
+	// Use MOTD as the server name for Minecraft
+	setCleanName(info, m.Name(), motd)
+
 	// Use central game detector to set the game field
 	info.Game = m.DetectGame(info)
 
+	// Clients compare the protocol number, not the version name, to decide whether they can join
+	if status.Version.Protocol != 0 {
+		if info.Extra == nil {
+			info.Extra = make(map[string]string)
+		}
+		info.Extra["protocol_version"] = strconv.Itoa(status.Version.Protocol)
+	}
+
+	// The status response already carries the player sample, so no follow-up query exists
+	if opts.Capabilities {
+		if info.Extra == nil {
+			info.Extra = make(map[string]string)
+		}
+		info.Extra["players_available"] = strconv.FormatBool(len(status.Players.Sample) > 0)
+	}
+
 	// Add player list if requested
 	if opts.Players {
 		if status.Players.Sample != nil {
 			if opts.Debug {
-				debugLogf("Minecraft", "Adding %d players to player list", len(status.Players.Sample))
+				debugLogf(opts, "Minecraft", "Adding %d players to player list", len(status.Players.Sample))
 			}
 			info.Players.List = make([]Player, len(status.Players.Sample))
 			for i, player := range status.Players.Sample {
@@ -183,18 +245,116 @@ func (m *MinecraftProtocol) Query(ctx context.Context, addr string, opts *Option
 			}
 		} else {
 			if opts.Debug {
-				debugLog("Minecraft", "No player sample available")
+				debugLog(opts, "Minecraft", "No player sample available")
 			}
 			info.Players.List = make([]Player, 0)
 		}
 	}
 
+	// Take further latency samples with ping/pong exchanges
+	if opts.PingSamples > 1 {
+		samples := append([]int{ping}, m.samplePing(ctx, conn, addr, host, port, opts.PingSamples-1, opts)...)
+		applyPingSamples(info, samples)
+	}
+
 	if opts.Debug {
-		debugLog("Minecraft", "Query completed successfully")
+		debugLog(opts, "Minecraft", "Query completed successfully")
 	}
 	return info, nil
 }
 
+// samplePing measures count ping/pong round trips, the first on the status connection.
+// Vanilla servers close the connection after a pong, so later samples reconnect and
+// handshake first. Sampling stops at the first failure.
+func (m *MinecraftProtocol) samplePing(ctx context.Context, conn net.Conn, addr, host string, port, count int, opts *Options) []int {
+	samples := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		sampleConn := conn
+		if i > 0 {
+			var err error
+			sampleConn, err = setupConnection(ctx, "tcp", addr, opts)
+			if err != nil {
+				break
+			}
+			if err := m.sendHandshake(sampleConn, host, port); err != nil {
+				sampleConn.Close()
+				break
+			}
+		}
+
+		ping, err := m.pingPong(sampleConn)
+		if i > 0 {
+			sampleConn.Close()
+		}
+		if err != nil {
+			if opts.Debug {
+				debugLogf(opts, "Minecraft", "Ping sample %d failed: %v", i+2, err)
+			}
+			break
+		}
+		samples = append(samples, ping)
+	}
+	return samples
+}
+
+// pingPong sends a ping packet and returns the round trip once the matching pong arrives
+func (m *MinecraftProtocol) pingPong(conn net.Conn) (int, error) {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().UnixNano()))
+	packet := append([]byte{0x01}, payload...)
+
+	start := time.Now()
+	if err := m.writeVarIntPrefixedData(conn, packet); err != nil {
+		return 0, fmt.Errorf("write ping failed: %w", err)
+	}
+	response, err := m.readVarIntPrefixedData(conn, len(packet), nil)
+	if err != nil {
+		return 0, fmt.Errorf("read pong failed: %w", err)
+	}
+	if !bytes.Equal(response, packet) {
+		return 0, fmt.Errorf("unexpected pong")
+	}
+	return int(math.Ceil(float64(time.Since(start).Nanoseconds()) / 1e6)), nil
+}
+
+// Probe performs the status handshake and accepts any status response packet without parsing its JSON
+func (m *MinecraftProtocol) Probe(ctx context.Context, addr string, opts *Options) error {
+	conn, err := setupConnection(ctx, "tcp", addr, opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid port: %w", err)
+	}
+
+	if err := m.sendHandshake(conn, host, port); err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+	if err := m.sendStatusRequest(conn); err != nil {
+		return fmt.Errorf("status request failed: %w", err)
+	}
+
+	// Only the packet length and ID are needed to recognise a status response
+	if _, err := m.readVarInt(conn); err != nil {
+		return fmt.Errorf("read response failed: %w", err)
+	}
+	packetID, err := m.readVarInt(conn)
+	if err != nil {
+		return fmt.Errorf("read packet ID failed: %w", err)
+	}
+	if packetID != 0x00 {
+		return fmt.Errorf("unexpected packet ID: %02x", packetID)
+	}
+	return nil
+}
+
 func (m *MinecraftProtocol) sendHandshake(conn net.Conn, host string, port int) error {
 	var buf bytes.Buffer
 	
@@ -274,21 +434,64 @@ func (m *MinecraftProtocol) readVarInt(reader io.Reader) (int, error) {
 	return result, nil
 }
 
-func (m *MinecraftProtocol) readVarIntPrefixedData(reader io.Reader) ([]byte, error) {
+// readVarIntPrefixedData reads a length-prefixed packet, rejecting lengths above maxLength
+// before allocating so a hostile server can't force a huge allocation. The body is read in
+// chunks and the buffer only grows as data arrives, so a declared length is never trusted
+// for one large allocation up front. beforeChunk, if set, runs before the length and
+// before each chunk of the body.
+func (m *MinecraftProtocol) readVarIntPrefixedData(reader io.Reader, maxLength int, beforeChunk func()) ([]byte, error) {
+	if beforeChunk != nil {
+		beforeChunk()
+	}
 	length, err := m.readVarInt(reader)
 	if err != nil {
 		return nil, err
 	}
-	
-	data := make([]byte, length)
-	if _, err := io.ReadFull(reader, data); err != nil {
-		return nil, err
+	if length < 0 || length > maxLength {
+		return nil, fmt.Errorf("%w: packet length %d, limit %d", errResponseTooLarge, length, maxLength)
 	}
-	
+
+	data := make([]byte, 0, min(length, minecraftChunkSize))
+	for len(data) < length {
+		start := len(data)
+		if beforeChunk != nil && start > 0 {
+			beforeChunk()
+		}
+		data = append(data, make([]byte, min(length-start, minecraftChunkSize))...)
+		if _, err := io.ReadFull(reader, data[start:]); err != nil {
+			return nil, err
+		}
+	}
+
 	return data, nil
 }
 
-func (m *MinecraftProtocol) cleanMotd(motd interface{}) string {
+// minecraftChunkSize is how much of a packet body is read at a time
+const minecraftChunkSize = 16 << 10
+
+// minecraftTransferTimeouts bounds a whole status response to this many timeouts, however
+// quickly each chunk arrives
+const minecraftTransferTimeouts = 4
+
+// chunkDeadline gives each chunk of a response idle to arrive, never past limit
+type chunkDeadline struct {
+	conn  net.Conn
+	idle  time.Duration
+	limit time.Time
+}
+
+// extend moves the read deadline idle into the future, capped at limit
+func (d *chunkDeadline) extend() {
+	deadline := time.Now().Add(d.idle)
+	if d.limit.Before(deadline) {
+		deadline = d.limit
+	}
+	d.conn.SetReadDeadline(deadline)
+}
+
+// motdText joins the text of a description, which is either a plain string or a chat
+// component, keeping the § formatting codes
+func (m *MinecraftProtocol) motdText(motd interface{}) string {
 	var text string
 	
 	switch v := motd.(type) {
@@ -311,11 +514,7 @@ func (m *MinecraftProtocol) cleanMotd(motd interface{}) string {
 		}
 	}
 	
-	// Remove Minecraft color codes and formatting
-	colorCodeRe := regexp.MustCompile(`§[0-9a-fk-or]`)
-	text = colorCodeRe.ReplaceAllString(text, "")
-	
-	return strings.TrimSpace(text)
+	return text
 }
 
 // MinecraftStatus represents the JSON response from a Minecraft server
@@ -5,6 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -59,6 +62,9 @@ type mockMinecraftServer struct {
 	t        *testing.T
 	listener net.Listener
 	response MinecraftStatus
+	drops    int32
+	raw      []byte
+	trickle  time.Duration
 }
 
 // newMockMinecraftServer creates and starts a new mock server.
@@ -88,6 +94,21 @@ func (s *mockMinecraftServer) Close() {
 	s.listener.Close()
 }
 
+// dropConnections makes the server reset the next n connections after the handshake.
+func (s *mockMinecraftServer) dropConnections(n int32) {
+	atomic.StoreInt32(&s.drops, n)
+}
+
+// setRawResponse makes the server answer status requests with data instead of a status packet.
+func (s *mockMinecraftServer) setRawResponse(data []byte) {
+	s.raw = data
+}
+
+// setTrickle makes the server send status responses in four parts, pausing between them.
+func (s *mockMinecraftServer) setTrickle(pause time.Duration) {
+	s.trickle = pause
+}
+
 // handleConnections accepts and handles incoming connections.
 func (s *mockMinecraftServer) handleConnections() {
 	for {
@@ -105,33 +126,70 @@ func (s *mockMinecraftServer) handleRequest(conn net.Conn) {
 
 	// 1. Read Handshake
 	p := &MinecraftProtocol{}
-	_, err := p.readVarIntPrefixedData(conn)
+	_, err := p.readVarIntPrefixedData(conn, defaultMaxStreamSize, nil)
 	if err != nil {
 		s.t.Logf("Error reading handshake: %v", err)
 		return
 	}
 
-	// 2. Read Status Request
-	_, err = p.readVarIntPrefixedData(conn)
-	if err != nil {
-		s.t.Logf("Error reading status request: %v", err)
+	// Simulate a rate limiting server resetting the connection mid-stream
+	if atomic.AddInt32(&s.drops, -1) >= 0 {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetLinger(0)
+		}
 		return
 	}
 
-	// 3. Write Status Response
-	jsonResponse, err := json.Marshal(s.response)
-	if err != nil {
-		s.t.Fatalf("Failed to marshal response: %v", err)
-	}
+	// 2. Answer status requests until the client hangs up or pings
+	for {
+		request, err := p.readVarIntPrefixedData(conn, defaultMaxStreamSize, nil)
+		if err != nil || len(request) == 0 {
+			return // Client closed the connection
+		}
+
+		// Ping: echo the payload as a pong and close, like vanilla servers
+		if request[0] == 0x01 {
+			p.writeVarIntPrefixedData(conn, request)
+			return
+		}
 
-	// Construct the response payload: Packet ID (0x00) + JSON Data
-	var payload bytes.Buffer
-	p.writeVarInt(&payload, 0x00) // Packet ID
-	p.writeString(&payload, string(jsonResponse))
+		if s.raw != nil {
+			conn.Write(s.raw)
+			return
+		}
+
+		// 3. Write Status Response
+		jsonResponse, err := json.Marshal(s.response)
+		if err != nil {
+			s.t.Errorf("Failed to marshal response: %v", err)
+			return
+		}
 
-	// Send the payload with a length prefix
-	if err := p.writeVarIntPrefixedData(conn, payload.Bytes()); err != nil {
-		s.t.Logf("Error writing response: %v", err)
+		// Construct the response payload: Packet ID (0x00) + JSON Data
+		var payload bytes.Buffer
+		p.writeVarInt(&payload, 0x00) // Packet ID
+		p.writeString(&payload, string(jsonResponse))
+
+		// Send the payload with a length prefix
+		if s.trickle > 0 {
+			var packet bytes.Buffer
+			p.writeVarInt(&packet, payload.Len())
+			packet.Write(payload.Bytes())
+			data := packet.Bytes()
+			part := len(data)/4 + 1
+			for len(data) > 0 {
+				n := min(part, len(data))
+				if _, err := conn.Write(data[:n]); err != nil {
+					return
+				}
+				data = data[n:]
+				time.Sleep(s.trickle)
+			}
+			continue
+		}
+		if err := p.writeVarIntPrefixedData(conn, payload.Bytes()); err != nil {
+			return
+		}
 	}
 }
 
@@ -162,6 +220,7 @@ func TestMinecraftProtocol_Query(t *testing.T) {
 		playersMax:     100,
 		playerNames:    []string{"Player1", "Player2"},
 	})
+	assert.Equal(t, "762", info.Extra["protocol_version"])
 }
 
 func TestMinecraftProtocol_Query_ComplexMOTD(t *testing.T) {
@@ -193,6 +252,7 @@ func TestMinecraftProtocol_Query_ComplexMOTD(t *testing.T) {
 		online:         true,
 		game:           "minecraft",
 		name:           "Welcome!A Multi-Line\nMOTD!",
+		nameRaw:        "Welcome!A Multi-Line\n§cMOTD!",
 		version:        "1.20.1",
 		playersCurrent: 1,
 		playersMax:     20,
@@ -257,11 +317,39 @@ func TestMinecraftProtocol_Query_NoPlayersRequested(t *testing.T) {
 	})
 }
 
+func TestMinecraftProtocol_Query_RetriesAfterReset(t *testing.T) {
+	// 1. Setup mock server that resets the first two connections
+	server := newMockMinecraftServer(t, createMinecraftStatus("", "1.20.4", 765, 7, 50, "Throttled Server"))
+	server.dropConnections(2)
+	defer server.Close()
+
+	// 2. Query without retries fails
+	protocol := &MinecraftProtocol{}
+	_, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+	assert.Error(t, err)
+
+	// 3. Query with retries recovers from the remaining reset
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, Retries: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "Throttled Server", info.Name)
+	assert.Equal(t, 7, info.Players.Current)
+}
+
+func TestMinecraftProtocol_Probe(t *testing.T) {
+	server := newMockMinecraftServer(t, createMinecraftStatus("", "1.20.4", 765, 3, 20, "Probe Server"))
+	defer server.Close()
+
+	protocol := &MinecraftProtocol{}
+	err := protocol.Probe(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+}
+
 // Helper struct for expected server info values
 type expectedServerInfo struct {
 	online         bool
 	game           string
 	name           string
+	nameRaw        string // Formatted name in Extra["name_raw"], empty when the name had no codes
 	version        string
 	playersCurrent int
 	playersMax     int
@@ -283,7 +371,13 @@ func assertServerInfo(t *testing.T, info *ServerInfo, expected expectedServerInf
 	assert.Zero(t, info.Port, "Port not set by protocol")
 	assert.Empty(t, info.Map, "Map field not used by Minecraft")
 	assert.Greater(t, info.Ping, 0, "Ping should be measured and greater than 0")
-	assert.Nil(t, info.Extra, "Extra fields should be nil")
+	assert.NotEmpty(t, info.Extra["protocol_version"])
+	if expected.nameRaw != "" {
+		assert.Equal(t, expected.nameRaw, info.Extra["name_raw"])
+		assert.Len(t, info.Extra, 2, "Extra should only carry the protocol version and raw name")
+	} else {
+		assert.Len(t, info.Extra, 1, "Extra should only carry the protocol version")
+	}
 	
 	// Player information
 	assert.Equal(t, expected.playersCurrent, info.Players.Current)
@@ -302,3 +396,152 @@ func assertServerInfo(t *testing.T, info *ServerInfo, expected expectedServerInf
 		assert.Nil(t, info.Players.List)
 	}
 }
+
+func TestMinecraftProtocol_Query_PingSamples(t *testing.T) {
+	server := newMockMinecraftServer(t, createMinecraftStatus("", "1.20.4", 765, 3, 20, "Ping Server"))
+	defer server.Close()
+
+	protocol := &MinecraftProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, PingSamples: 4})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Ping Server", info.Name)
+	assert.Contains(t, info.Extra, "ping_min")
+	assert.Contains(t, info.Extra, "ping_max")
+	pingMin, _ := strconv.Atoi(info.Extra["ping_min"])
+	pingMax, _ := strconv.Atoi(info.Extra["ping_max"])
+	assert.LessOrEqual(t, pingMin, info.Ping)
+	assert.GreaterOrEqual(t, pingMax, info.Ping)
+}
+
+func TestMinecraftProtocol_ReadVarIntPrefixedData_Limit(t *testing.T) {
+	p := &MinecraftProtocol{}
+
+	var packet bytes.Buffer
+	p.writeVarInt(&packet, 3)
+	packet.WriteString("abc")
+	data, err := p.readVarIntPrefixedData(bytes.NewReader(packet.Bytes()), 3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abc"), data)
+
+	// The length is rejected before any allocation or read of the body
+	var huge bytes.Buffer
+	p.writeVarInt(&huge, 1<<30)
+	_, err = p.readVarIntPrefixedData(bytes.NewReader(huge.Bytes()), defaultMaxStreamSize, nil)
+	assert.ErrorIs(t, err, errResponseTooLarge)
+}
+
+func TestMinecraftProtocol_Query_AbsurdLengths(t *testing.T) {
+	p := &MinecraftProtocol{}
+
+	// Outer packet length of 1 GiB with no body behind it
+	var outer bytes.Buffer
+	p.writeVarInt(&outer, 1<<30)
+
+	// Small packet whose JSON string claims to be 1 GiB
+	var body bytes.Buffer
+	p.writeVarInt(&body, 0x00)
+	p.writeVarInt(&body, 1<<30)
+	body.WriteString("{}")
+	var inner bytes.Buffer
+	p.writeVarInt(&inner, body.Len())
+	inner.Write(body.Bytes())
+
+	tests := []struct {
+		name     string
+		response []byte
+		contains string
+	}{
+		{"packet length", outer.Bytes(), "exceeds size limit"},
+		{"JSON length", inner.Bytes(), "invalid JSON length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockMinecraftServer(t, MinecraftStatus{})
+			server.setRawResponse(tt.response)
+			defer server.Close()
+
+			start := time.Now()
+			_, err := p.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, Retries: 2})
+
+			assert.ErrorContains(t, err, tt.contains)
+			assert.Less(t, time.Since(start), time.Second, "oversized responses must not be retried or waited on")
+		})
+	}
+
+	// The packet limit follows MaxResponseSize
+	server := newMockMinecraftServer(t, createMinecraftStatus("", "1.20.4", 765, 1, 20, "A server with a long enough MOTD"))
+	defer server.Close()
+	_, err := p.Query(context.Background(), server.Addr(), &Options{Timeout: 5 * time.Second, MaxResponseSize: 16})
+	assert.ErrorIs(t, err, errResponseTooLarge)
+}
+
+func TestMinecraftProtocol_Query_TrickledLargeResponse(t *testing.T) {
+	// A 100 KB favicon sent in four parts 150ms apart takes longer than the 300ms timeout
+	status := createMinecraftStatus("", "1.20.4", 765, 1, 20, "Slow Network")
+	withFavicon(&status, "data:image/png;base64,"+strings.Repeat("A", 100<<10))
+	server := newMockMinecraftServer(t, status)
+	server.setTrickle(150 * time.Millisecond)
+	defer server.Close()
+
+	protocol := &MinecraftProtocol{}
+	info, err := protocol.Query(context.Background(), server.Addr(), &Options{Timeout: 300 * time.Millisecond})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Slow Network", info.Name)
+
+	// The context deadline still bounds the transfer
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	_, err = protocol.Query(ctx, server.Addr(), &Options{Timeout: 300 * time.Millisecond})
+	assert.Error(t, err)
+}
+
+func TestMinecraftProtocol_Query_TrickleIsBounded(t *testing.T) {
+	tests := []struct {
+		name  string
+		piece int
+	}{
+		// One byte per pause never completes a chunk in time
+		{"bytes", 1},
+		// Whole chunks just inside the timeout would take seconds for the declared length
+		{"chunks", minecraftChunkSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to start mock server: %v", err)
+			}
+			defer l.Close()
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				conn.Read(make([]byte, 512)) // Handshake and status request
+
+				var length bytes.Buffer
+				(&MinecraftProtocol{}).writeVarInt(&length, 1<<20)
+				conn.Write(length.Bytes())
+				piece := bytes.Repeat([]byte{'A'}, tt.piece)
+				for {
+					time.Sleep(80 * time.Millisecond)
+					if _, err := conn.Write(piece); err != nil {
+						return
+					}
+				}
+			}()
+
+			// No context deadline, so only the protocol's own limit stops the transfer
+			protocol := &MinecraftProtocol{}
+			start := time.Now()
+			_, err = protocol.Query(context.Background(), l.Addr().String(), &Options{Timeout: 100 * time.Millisecond})
+
+			assert.Error(t, err)
+			assert.Less(t, time.Since(start), minecraftTransferTimeouts*100*time.Millisecond+300*time.Millisecond)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinecraftQueryProtocol implements the GameSpy4 based Minecraft Query protocol (enable-query=true)
+type MinecraftQueryProtocol struct{}
+
+func init() {
+	registry.Register(&MinecraftQueryProtocol{})
+}
+
+func (m *MinecraftQueryProtocol) Name() string {
+	return "minecraft-query"
+}
+
+func (m *MinecraftQueryProtocol) Network() string {
+	return "udp"
+}
+
+func (m *MinecraftQueryProtocol) DefaultPort() int {
+	return 25565
+}
+
+func (m *MinecraftQueryProtocol) DefaultQueryPort() int {
+	return 25565
+}
+
+func (m *MinecraftQueryProtocol) Games() []GameConfig {
+	return []GameConfig{
+		{Name: "minecraft-query", GamePort: 25565, QueryPort: 25565},
+	}
+}
+
+func (m *MinecraftQueryProtocol) DetectGame(info *ServerInfo) string {
+	return "minecraft"
+}
+
+// GameSpy4 packet types
+const (
+	gamespy4Handshake = 0x09
+	gamespy4Stat      = 0x00
+)
+
+// gamespy4SessionID identifies our requests; Minecraft ignores the high nibble of each byte
+const gamespy4SessionID = 0x01020304 & 0x0F0F0F0F
+
+func (m *MinecraftQueryProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
+	if opts.Debug {
+		debugLogf(opts, "MinecraftQuery", "Starting query for %s", addr)
+	}
+
+	conn, err := setupConnection(ctx, "udp", addr, opts)
+	if err != nil {
+		return &ServerInfo{Online: false}, err
+	}
+	defer conn.Close()
+
+	challenge, err := m.handshake(conn)
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "MinecraftQuery", "Handshake failed: %v", err)
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("handshake failed: %w", err)
+	}
+	if opts.Debug {
+		debugLogf(opts, "MinecraftQuery", "Challenge token: %d", challenge)
+	}
+
+	// Full stat request: the padding selects the full response over the basic one
+	var request bytes.Buffer
+	request.Write([]byte{0xFE, 0xFD, gamespy4Stat})
+	binary.Write(&request, binary.BigEndian, uint32(gamespy4SessionID))
+	binary.Write(&request, binary.BigEndian, challenge)
+	request.Write([]byte{0x00, 0x00, 0x00, 0x00})
+
+	pingStart := time.Now()
+	if _, err := conn.Write(request.Bytes()); err != nil {
+		return &ServerInfo{Online: false}, fmt.Errorf("write full stat failed: %w", err)
+	}
+
+	response := make([]byte, 4096)
+	n, err := conn.Read(response)
+	ping := int(math.Ceil(float64(time.Since(pingStart).Nanoseconds()) / 1e6))
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "MinecraftQuery", "Full stat read failed: %v", err)
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("read failed: %w", err)
+	}
+	if opts.Debug {
+		debugLogf(opts, "MinecraftQuery", "Received %d bytes full stat response (ping: %dms)", n, ping)
+	}
+
+	values, players, err := m.parseFullStat(response[:n])
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "MinecraftQuery", "Full stat parsing failed: %v", err)
+		}
+		return &ServerInfo{Online: false}, fmt.Errorf("parse failed: %w", err)
+	}
+
+	info := &ServerInfo{
+		Version: values["version"],
+		Map:     values["map"],
+		Ping:    ping,
+		Online:  true,
+		Extra: map[string]string{
+			"game_type": values["gametype"],
+		},
+	}
+	setCleanName(info, m.Name(), values["hostname"])
+	info.Players.Current, _ = strconv.Atoi(values["numplayers"])
+	info.Players.Max, _ = strconv.Atoi(values["maxplayers"])
+	if hostPort := values["hostport"]; hostPort != "" {
+		info.Extra["game_port"] = hostPort
+	}
+
+	// Vanilla servers leave plugins empty; Bukkit derivatives report "Software: Plugin; Plugin"
+	software, plugins := m.parsePlugins(values["plugins"])
+	if software != "" {
+		info.Extra["software"] = software
+	}
+	info.Plugins = plugins
+
+	info.Game = m.DetectGame(info)
+
+	if opts.Players {
+		info.Players.List = make([]Player, 0, len(players))
+		for _, name := range players {
+			info.Players.List = append(info.Players.List, Player{Name: name})
+		}
+	}
+
+	if opts.Debug {
+		debugLogf(opts, "MinecraftQuery", "Query completed: %s (%d/%d, %d plugins)", info.Name, info.Players.Current, info.Players.Max, len(info.Plugins))
+	}
+	return info, nil
+}
+
+// Probe performs the GameSpy4 handshake, which any query-enabled server answers
+func (m *MinecraftQueryProtocol) Probe(ctx context.Context, addr string, opts *Options) error {
+	conn, err := setupConnection(ctx, "udp", addr, opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := m.handshake(conn); err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+	return nil
+}
+
+// handshake requests a challenge token, which the server sends as a decimal string
+func (m *MinecraftQueryProtocol) handshake(conn net.Conn) (uint32, error) {
+	var request bytes.Buffer
+	request.Write([]byte{0xFE, 0xFD, gamespy4Handshake})
+	binary.Write(&request, binary.BigEndian, uint32(gamespy4SessionID))
+	if _, err := conn.Write(request.Bytes()); err != nil {
+		return 0, err
+	}
+
+	response := make([]byte, 64)
+	n, err := conn.Read(response)
+	if err != nil {
+		return 0, err
+	}
+	if n < 6 || response[0] != gamespy4Handshake || binary.BigEndian.Uint32(response[1:5]) != gamespy4SessionID {
+		return 0, fmt.Errorf("invalid handshake response")
+	}
+
+	token := strings.TrimRight(string(response[5:n]), "\x00")
+	challenge, err := strconv.ParseInt(token, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid challenge token: %q", token)
+	}
+	return uint32(int32(challenge)), nil
+}
+
+// parseFullStat splits a full stat response into its key/value section and player names
+func (m *MinecraftQueryProtocol) parseFullStat(data []byte) (map[string]string, []string, error) {
+	if len(data) < 5 || data[0] != gamespy4Stat || binary.BigEndian.Uint32(data[1:5]) != gamespy4SessionID {
+		return nil, nil, fmt.Errorf("invalid full stat response")
+	}
+
+	// The key/value section follows a fixed "splitnum\x00\x80\x00" padding
+	body := data[5:]
+	padding := []byte("splitnum\x00\x80\x00")
+	if !bytes.HasPrefix(body, padding) {
+		return nil, nil, fmt.Errorf("missing full stat padding")
+	}
+	body = body[len(padding):]
+
+	sections := bytes.SplitN(body, []byte("\x00\x00\x01player_\x00\x00"), 2)
+	values := make(map[string]string)
+	fields := strings.Split(string(sections[0]), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "" {
+			break
+		}
+		values[fields[i]] = fields[i+1]
+	}
+
+	var players []string
+	if len(sections) == 2 {
+		for _, name := range strings.Split(string(sections[1]), "\x00") {
+			if name != "" {
+				players = append(players, name)
+			}
+		}
+	}
+	return values, players, nil
+}
+
+// parsePlugins splits the plugins field, e.g. "Paper on Bukkit 1.20.4: EssentialsX 2.19.0; WorldEdit 7.2",
+// into the server software and its plugin list
+func (m *MinecraftQueryProtocol) parsePlugins(field string) (string, []string) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return "", nil
+	}
+
+	software, pluginList, found := strings.Cut(field, ":")
+	software = strings.TrimSpace(software)
+	if !found {
+		return software, nil
+	}
+
+	var plugins []string
+	for _, plugin := range strings.Split(pluginList, ";") {
+		if plugin = strings.TrimSpace(plugin); plugin != "" {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return software, plugins
+}
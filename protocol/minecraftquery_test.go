@@ -0,0 +1,145 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startMockMinecraftQueryServer answers GameSpy4 handshakes and full stat requests with the given values.
+func startMockMinecraftQueryServer(t *testing.T, values [][2]string, players []string) string {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		buffer := make([]byte, 1400)
+		for {
+			n, addr, err := l.ReadFrom(buffer)
+			if err != nil {
+				return // Listener closed
+			}
+			if response := mockMinecraftQueryResponse(buffer[:n], values, players); response != nil {
+				l.WriteTo(response, addr)
+			}
+		}
+	}()
+
+	return l.LocalAddr().String()
+}
+
+// mockMinecraftQueryResponse answers a GameSpy4 handshake or full stat request, returning
+// nil for anything else
+func mockMinecraftQueryResponse(packet []byte, values [][2]string, players []string) []byte {
+	const challenge = int32(-9513307)
+
+	if len(packet) < 7 || packet[0] != 0xFE || packet[1] != 0xFD {
+		return nil
+	}
+	session := packet[3:7]
+
+	var response bytes.Buffer
+	switch packet[2] {
+	case 0x09: // Handshake
+		response.WriteByte(0x09)
+		response.Write(session)
+		response.WriteString("-9513307\x00")
+	case 0x00: // Full stat
+		if len(packet) < 15 || int32(binary.BigEndian.Uint32(packet[7:11])) != challenge {
+			return nil
+		}
+		response.WriteByte(0x00)
+		response.Write(session)
+		response.WriteString("splitnum\x00\x80\x00")
+		for _, kv := range values {
+			response.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+		}
+		response.WriteString("\x00\x01player_\x00\x00")
+		for _, player := range players {
+			response.WriteString(player + "\x00")
+		}
+		response.WriteByte(0x00)
+	default:
+		return nil
+	}
+	return response.Bytes()
+}
+
+func TestMinecraftQueryProtocol_Query(t *testing.T) {
+	addr := startMockMinecraftQueryServer(t, [][2]string{
+		{"hostname", "A Paper Server"},
+		{"gametype", "SMP"},
+		{"game_id", "MINECRAFT"},
+		{"version", "1.20.4"},
+		{"plugins", "Paper on Bukkit 1.20.4-R0.1: EssentialsX 2.19.0; WorldEdit 7.2.15"},
+		{"map", "world"},
+		{"numplayers", "2"},
+		{"maxplayers", "20"},
+		{"hostport", "25565"},
+		{"hostip", "127.0.0.1"},
+	}, []string{"Steve", "Alex"})
+
+	protocol := &MinecraftQueryProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Equal(t, "minecraft", info.Game)
+	assert.Equal(t, "A Paper Server", info.Name)
+	assert.Equal(t, "1.20.4", info.Version)
+	assert.Equal(t, "world", info.Map)
+	assert.Equal(t, 2, info.Players.Current)
+	assert.Equal(t, 20, info.Players.Max)
+	assert.Equal(t, []Player{{Name: "Steve"}, {Name: "Alex"}}, info.Players.List)
+	assert.Equal(t, "Paper on Bukkit 1.20.4-R0.1", info.Extra["software"])
+	assert.Equal(t, []string{"EssentialsX 2.19.0", "WorldEdit 7.2.15"}, info.Plugins)
+}
+
+func TestMinecraftQueryProtocol_Query_Vanilla(t *testing.T) {
+	addr := startMockMinecraftQueryServer(t, [][2]string{
+		{"hostname", "Vanilla"},
+		{"version", "1.20.4"},
+		{"plugins", ""},
+		{"numplayers", "0"},
+		{"maxplayers", "10"},
+	}, nil)
+
+	protocol := &MinecraftQueryProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second, Players: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Vanilla", info.Name)
+	assert.Empty(t, info.Players.List)
+	assert.Nil(t, info.Plugins)
+	assert.NotContains(t, info.Extra, "software")
+}
+
+func TestMinecraftQueryProtocol_Query_FormattedHostname(t *testing.T) {
+	addr := startMockMinecraftQueryServer(t, [][2]string{
+		{"hostname", "§6Gold §rSMP"},
+		{"numplayers", "0"},
+		{"maxplayers", "10"},
+	}, nil)
+
+	protocol := &MinecraftQueryProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 5 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Gold SMP", info.Name)
+	assert.Equal(t, "§6Gold §rSMP", info.Extra["name_raw"])
+}
+
+func TestMinecraftQueryProtocol_Probe(t *testing.T) {
+	addr := startMockMinecraftQueryServer(t, nil, nil)
+
+	protocol := &MinecraftQueryProtocol{}
+	err := protocol.Probe(context.Background(), addr, &Options{Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,307 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RconProtocol reads server status over the Source RCON protocol, which Minecraft's RCON
+// shares. It needs the password in Options.RconPassword, so it is not registered for
+// auto-detection; the query package runs it alongside the public query when asked to.
+type RconProtocol struct{}
+
+// RCON packet types
+const (
+	rconResponseValue = 0
+	rconExecCommand   = 2
+	rconAuthResponse  = 2
+	rconAuth          = 3
+)
+
+// RCON request IDs; the terminator is an empty packet sent after the command whose echo
+// marks the end of a response the server split over several packets
+const (
+	rconAuthID       = 1
+	rconCommandID    = 2
+	rconTerminatorID = 3
+)
+
+// rconMaxPacketSize bounds a single packet; servers send at most 4096 bytes of body
+const rconMaxPacketSize = 4096 + 14
+
+// errRconAuthFailed reports a password the server rejected
+var errRconAuthFailed = errors.New("rcon authentication failed")
+
+func (r *RconProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
+	if opts.RconPassword == "" {
+		return &ServerInfo{Online: false}, fmt.Errorf("no rcon password")
+	}
+	if opts.Debug {
+		debugLogf(opts, "RCON", "Starting RCON status for %s", addr)
+	}
+
+	conn, err := setupConnection(ctx, "tcp", addr, opts)
+	if err != nil {
+		return &ServerInfo{Online: false}, err
+	}
+	defer conn.Close()
+
+	if err := r.authenticate(conn, opts.RconPassword); err != nil {
+		return &ServerInfo{Online: false}, err
+	}
+
+	// Source servers answer "status"; Minecraft has no such command and answers "list"
+	start := time.Now()
+	status, err := r.command(conn, "status")
+	if err != nil {
+		return &ServerInfo{Online: false}, fmt.Errorf("status command failed: %w", err)
+	}
+	if strings.Contains(status, "hostname") {
+		info := parseSourceStatus(status)
+		info.Ping = int(time.Since(start).Milliseconds())
+		return info, nil
+	}
+
+	list, err := r.command(conn, "list")
+	if err != nil {
+		return &ServerInfo{Online: false}, fmt.Errorf("list command failed: %w", err)
+	}
+	info, err := parseMinecraftList(list)
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "RCON", "Unrecognized list output %q", list)
+		}
+		return &ServerInfo{Online: false}, err
+	}
+	info.Ping = int(time.Since(start).Milliseconds())
+	return info, nil
+}
+
+// authenticate logs in with password. Source servers send an empty response value before
+// the auth response, which is skipped.
+func (r *RconProtocol) authenticate(conn net.Conn, password string) error {
+	if err := writeRconPacket(conn, rconAuthID, rconAuth, password); err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	for {
+		id, packetType, _, err := readRconPacket(conn)
+		if err != nil {
+			return fmt.Errorf("auth response failed: %w", err)
+		}
+		if packetType != rconAuthResponse {
+			continue
+		}
+		if id == -1 {
+			return errRconAuthFailed
+		}
+		return nil
+	}
+}
+
+// command runs cmd and returns its output, joining packets until the terminator's echo.
+// Servers that ignore the terminator get until the deadline, and whatever arrived by then
+// is returned.
+func (r *RconProtocol) command(conn net.Conn, cmd string) (string, error) {
+	if err := writeRconPacket(conn, rconCommandID, rconExecCommand, cmd); err != nil {
+		return "", fmt.Errorf("write failed: %w", err)
+	}
+	if err := writeRconPacket(conn, rconTerminatorID, rconResponseValue, ""); err != nil {
+		return "", fmt.Errorf("write failed: %w", err)
+	}
+
+	var output strings.Builder
+	received := false
+	for {
+		id, _, body, err := readRconPacket(conn)
+		if err != nil {
+			if received {
+				return output.String(), nil
+			}
+			return "", err
+		}
+		switch id {
+		case rconCommandID:
+			output.WriteString(body)
+			received = true
+		case rconTerminatorID:
+			return output.String(), nil
+		}
+	}
+}
+
+// writeRconPacket writes a packet: little-endian size, ID and type, then the body followed
+// by two null bytes
+func writeRconPacket(w io.Writer, id, packetType int32, body string) error {
+	var packet bytes.Buffer
+	binary.Write(&packet, binary.LittleEndian, int32(4+4+len(body)+2))
+	binary.Write(&packet, binary.LittleEndian, id)
+	binary.Write(&packet, binary.LittleEndian, packetType)
+	packet.WriteString(body)
+	packet.Write([]byte{0, 0})
+	_, err := w.Write(packet.Bytes())
+	return err
+}
+
+// readRconPacket reads one packet, rejecting sizes outside what the protocol allows
+func readRconPacket(reader io.Reader) (id, packetType int32, body string, err error) {
+	var size int32
+	if err = binary.Read(reader, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	if size < 10 || size > rconMaxPacketSize {
+		return 0, 0, "", fmt.Errorf("invalid rcon packet size %d", size)
+	}
+
+	packet := make([]byte, size)
+	if _, err = io.ReadFull(reader, packet); err != nil {
+		return 0, 0, "", err
+	}
+	id = int32(binary.LittleEndian.Uint32(packet[0:4]))
+	packetType = int32(binary.LittleEndian.Uint32(packet[4:8]))
+	return id, packetType, strings.TrimRight(string(packet[8:]), "\x00"), nil
+}
+
+// sourceStatusPlayers matches the "players : 2 humans, 1 bots (16 max)" line of a Source
+// status
+var sourceStatusPlayers = regexp.MustCompile(`(\d+) humans?, (\d+) bots? \((\d+)(?:/\d+)? max`)
+
+// parseSourceStatus reads the header and player table of a Source status, e.g.
+//
+//	hostname: My Server
+//	version : 1.38.7.9/13879 8898 secure
+//	map     : de_dust2 at: 0 x, 0 y, 0 z
+//	players : 1 humans, 1 bots (16 max)
+//	# userid name uniqueid connected ping loss state adr
+//	#      2 "Player" STEAM_1:0:123 05:12 45 0 active 203.0.113.7:27005
+//	#      3 "BOT Bob" BOT active
+//
+// Games add or drop columns, so player fields are read relative to the quoted name and
+// only where they parse.
+func parseSourceStatus(status string) *ServerInfo {
+	info := &ServerInfo{
+		Online: true,
+		Extra:  map[string]string{"rcon": "true"},
+	}
+
+	for _, line := range strings.Split(status, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			if player, ok := parseSourceStatusPlayer(line); ok {
+				info.Players.List = append(info.Players.List, player)
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "hostname":
+			info.Name = value
+		case "version":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				info.Version = fields[0]
+			}
+		case "map":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				info.Map = fields[0]
+			}
+		case "players":
+			if m := sourceStatusPlayers.FindStringSubmatch(value); m != nil {
+				humans, _ := strconv.Atoi(m[1])
+				bots, _ := strconv.Atoi(m[2])
+				info.Players.Current = humans + bots
+				info.Players.Max, _ = strconv.Atoi(m[3])
+				info.Extra["bots"] = m[2]
+			}
+		}
+	}
+
+	if info.Players.Current == 0 {
+		info.Players.Current = len(info.Players.List)
+	}
+	return info
+}
+
+// parseSourceStatusPlayer reads one row of the status player table; the column header
+// row has no quoted name and is skipped
+func parseSourceStatusPlayer(line string) (Player, bool) {
+	first := strings.Index(line, `"`)
+	last := strings.LastIndex(line, `"`)
+	if first < 0 || last <= first {
+		return Player{}, false
+	}
+
+	player := Player{Name: line[first+1 : last]}
+	fields := strings.Fields(line[last+1:])
+	if len(fields) == 0 {
+		return player, true
+	}
+	if fields[0] == "BOT" {
+		player.Bot = true
+		return player, true
+	}
+
+	if len(fields) > 1 {
+		player.Duration = parseStatusDuration(fields[1])
+	}
+	if len(fields) > 2 {
+		player.Ping, _ = strconv.Atoi(fields[2])
+	}
+	if addr := fields[len(fields)-1]; len(fields) > 3 {
+		if _, _, err := net.SplitHostPort(addr); err == nil {
+			player.Address = addr
+		}
+	}
+	return player, true
+}
+
+// parseStatusDuration reads a connected time of the form "05:12" or "1:05:12"
+func parseStatusDuration(value string) time.Duration {
+	var total time.Duration
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + time.Duration(n)*time.Second
+	}
+	return total
+}
+
+// minecraftListPattern matches the output of Minecraft's list command, both the current
+// "There are 2 of a max of 20 players online: Alice, Bob" and the older
+// "There are 2/20 players online:" followed by the names
+var minecraftListPattern = regexp.MustCompile(`(?s)There are (\d+)(?: of a max of |/)(\d+) players online:(.*)`)
+
+// parseMinecraftList reads the output of Minecraft's list command
+func parseMinecraftList(list string) (*ServerInfo, error) {
+	m := minecraftListPattern.FindStringSubmatch(list)
+	if m == nil {
+		return nil, fmt.Errorf("unrecognized list output")
+	}
+
+	info := &ServerInfo{
+		Online: true,
+		Extra:  map[string]string{"rcon": "true"},
+	}
+	info.Players.Current, _ = strconv.Atoi(m[1])
+	info.Players.Max, _ = strconv.Atoi(m[2])
+	for _, name := range strings.Split(m[3], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			info.Players.List = append(info.Players.List, Player{Name: name})
+		}
+	}
+	return info, nil
+}
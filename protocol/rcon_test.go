@@ -0,0 +1,137 @@
+package protocol
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sourceStatusOutput = `hostname: Admin Test Server
+version : 1.38.7.9/13879 8898 secure
+udp/ip  : 0.0.0.0:27015
+map     : de_dust2 at: 0 x, 0 y, 0 z
+players : 2 humans, 1 bots (16 max)
+
+# userid name uniqueid connected ping loss state adr
+#      2 "Alice" STEAM_1:0:123 05:12 45 0 active 203.0.113.7:27005
+#      3 "Bob "the" Builder" STEAM_1:1:456 1:02:03 80 0 active 198.51.100.2:27005
+#      4 "BOT Carl" BOT active
+`
+
+// startMockRconServer accepts password and answers commands from responses, splitting
+// bodies into chunks of chunkSize bytes. Like Source servers, it sends an empty response
+// value before the auth response and echoes the empty terminator packet.
+func startMockRconServer(t *testing.T, password string, responses map[string]string, chunkSize int) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return // Listener closed
+			}
+			go serveMockRcon(conn, password, responses, chunkSize)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func serveMockRcon(conn net.Conn, password string, responses map[string]string, chunkSize int) {
+	defer conn.Close()
+	for {
+		id, packetType, body, err := readRconPacket(conn)
+		if err != nil {
+			return
+		}
+		switch packetType {
+		case rconAuth:
+			writeRconPacket(conn, id, rconResponseValue, "")
+			if body != password {
+				id = -1
+			}
+			writeRconPacket(conn, id, rconAuthResponse, "")
+		case rconExecCommand:
+			response, ok := responses[body]
+			if !ok {
+				response = "Unknown command: " + body
+			}
+			for len(response) > chunkSize {
+				writeRconPacket(conn, id, rconResponseValue, response[:chunkSize])
+				response = response[chunkSize:]
+			}
+			writeRconPacket(conn, id, rconResponseValue, response)
+		case rconResponseValue:
+			writeRconPacket(conn, id, rconResponseValue, "")
+		}
+	}
+}
+
+func TestRconProtocol_Query_SourceStatus(t *testing.T) {
+	// Split the status across several packets
+	addr := startMockRconServer(t, "secret", map[string]string{"status": sourceStatusOutput}, 64)
+
+	protocol := &RconProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second, RconPassword: "secret"})
+
+	assert.NoError(t, err)
+	assert.True(t, info.Online)
+	assert.Equal(t, "Admin Test Server", info.Name)
+	assert.Equal(t, "1.38.7.9/13879", info.Version)
+	assert.Equal(t, "de_dust2", info.Map)
+	assert.Equal(t, 3, info.Players.Current)
+	assert.Equal(t, 16, info.Players.Max)
+	assert.Equal(t, "true", info.Extra["rcon"])
+	assert.Equal(t, []Player{
+		{Name: "Alice", Duration: 5*time.Minute + 12*time.Second, Ping: 45, Address: "203.0.113.7:27005"},
+		{Name: `Bob "the" Builder`, Duration: time.Hour + 2*time.Minute + 3*time.Second, Ping: 80, Address: "198.51.100.2:27005"},
+		{Name: "BOT Carl", Bot: true},
+	}, info.Players.List)
+}
+
+func TestRconProtocol_Query_MinecraftList(t *testing.T) {
+	addr := startMockRconServer(t, "secret", map[string]string{
+		"list": "There are 2 of a max of 20 players online: Steve, Alex",
+	}, 4096)
+
+	protocol := &RconProtocol{}
+	info, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second, RconPassword: "secret"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, info.Players.Current)
+	assert.Equal(t, 20, info.Players.Max)
+	assert.Equal(t, []Player{{Name: "Steve"}, {Name: "Alex"}}, info.Players.List)
+}
+
+func TestRconProtocol_Query_WrongPassword(t *testing.T) {
+	addr := startMockRconServer(t, "secret", nil, 4096)
+
+	protocol := &RconProtocol{}
+	_, err := protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second, RconPassword: "guess"})
+	assert.ErrorIs(t, err, errRconAuthFailed)
+
+	_, err = protocol.Query(context.Background(), addr, &Options{Timeout: 2 * time.Second})
+	assert.Error(t, err)
+}
+
+func TestParseMinecraftList(t *testing.T) {
+	info, err := parseMinecraftList("There are 1/10 players online:\nNotch")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.Players.Current)
+	assert.Equal(t, 10, info.Players.Max)
+	assert.Equal(t, []Player{{Name: "Notch"}}, info.Players.List)
+
+	info, err = parseMinecraftList("There are 0 of a max of 20 players online: ")
+	assert.NoError(t, err)
+	assert.Empty(t, info.Players.List)
+
+	_, err = parseMinecraftList("Unknown or incomplete command")
+	assert.Error(t, err)
+}
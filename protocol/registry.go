@@ -2,9 +2,17 @@ package protocol
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -36,6 +44,18 @@ type Protocol interface {
 	DetectGame(info *ServerInfo) string
 }
 
+// Prober is implemented by protocols that can check reachability more cheaply than a full Query
+type Prober interface {
+	// Probe sends the protocol's probe and returns nil on any valid response, skipping field parsing
+	Probe(ctx context.Context, addr string, opts *Options) error
+}
+
+// NetworkProtocol is implemented by protocols that query over a single transport
+type NetworkProtocol interface {
+	// Network returns the transport used on the query port ("udp" or "tcp")
+	Network() string
+}
+
 // ServerInfo represents information about a game server
 type ServerInfo struct {
 	Name      string            `json:"name"`
@@ -46,8 +66,13 @@ type ServerInfo struct {
 	QueryPort int               `json:"query_port"`
 	Players   PlayerInfo        `json:"players"`
 	Map       string            `json:"map,omitempty"`
+	Region    string            `json:"region,omitempty"`
+	Language  string            `json:"language,omitempty"`
+	Plugins   []string          `json:"plugins,omitempty"`
+	Rules     map[string]string `json:"rules,omitempty"`
 	Ping      int               `json:"ping"`
 	Online    bool              `json:"online"`
+	QueriedAt time.Time         `json:"queried_at"` // When the response arrived, or the query failed
 	Extra     map[string]string `json:"extra,omitempty"`
 }
 
@@ -63,18 +88,58 @@ type Player struct {
 	Name     string        `json:"name"`
 	Score    int           `json:"score,omitempty"`
 	Duration time.Duration `json:"duration,omitempty"`
+	Ping     int           `json:"ping,omitempty"`    // Milliseconds, where the protocol reports it
+	Team     string        `json:"team,omitempty"`    // Team or faction, where the protocol reports it
+	Bot      bool          `json:"bot,omitempty"`     // Set where the protocol flags bot players
+	Address  string        `json:"address,omitempty"` // Player's IP and port, only from RCON status
+}
+
+// JoinedAt estimates when the player joined from the Duration reported in a response
+// received at queriedAt, usually the server's QueriedAt. It returns the zero time when the
+// protocol reports no duration.
+func (p Player) JoinedAt(queriedAt time.Time) time.Time {
+	if p.Duration <= 0 {
+		return time.Time{}
+	}
+	return queriedAt.Add(-p.Duration)
 }
 
 // Options configures how queries are performed
 type Options struct {
+	// Timeout bounds each exchange rather than the whole query: A2S player and rules
+	// sub-queries get half of it each after the info exchange, so a sequential A2S query
+	// can take up to twice Timeout. Use a context deadline to bound the whole query.
 	Timeout time.Duration
 	Port    int
 	Players bool
 	// Discovery options
-	PortRange      []int // Custom ports to scan
-	MaxConcurrency int   // Maximum concurrent queries (0 = unlimited)
-	DiscoveryMode  bool  // Whether this is a discovery scan (uses shorter timeouts)
-	Debug          bool  // Enable debug logging
+	PortRange       []int         // Custom ports to scan
+	MaxConcurrency  int           // Maximum concurrent queries (0 = unlimited)
+	DiscoveryMode   bool          // Whether this is a discovery scan (uses shorter timeouts)
+	Debug           bool          // Enable debug logging
+	Logger          *slog.Logger  // Destination for debug logs (nil = stderr)
+	Capabilities    bool          // Record likely supported follow-up queries in Extra
+	Retries         int           // Extra attempts for protocols that support retrying
+	ParallelQueries bool          // Run sub-queries concurrently on their own sockets
+	PingSamples     int           // Latency samples to take per query (0 or 1 = single measurement)
+	HTTPTimeout     time.Duration // Timeout for HTTP-based queries (0 = same as Timeout)
+	MaxResponseSize int           // Largest response to read in bytes (0 = default)
+	Rules           bool          // Query server rules (cvars) where the protocol supports it
+	// LookupHost resolves hostnames before dialing (nil = system resolver)
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+	// Dial opens connections in place of the default dialer (nil = net.Dialer)
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Timings receives the DNS and connect durations of the first connection (nil = not recorded)
+	Timings *Timings
+	// TLSConfig is used for HTTPS requests and makes HTTP-based protocols try HTTPS first (nil = plain HTTP first)
+	TLSConfig *tls.Config
+	// MaxRedirects is how many redirects HTTP-based protocols follow (0 = none)
+	MaxRedirects int
+	// RconPassword authenticates RconProtocol queries
+	RconPassword string
+	// QueryID and QueryTarget tag debug lines so those of concurrent queries can be told apart (empty = untagged)
+	QueryID     string
+	QueryTarget string
 }
 
 // Registry manages protocol registration
@@ -100,6 +165,16 @@ func (r *Registry) Register(protocol Protocol) {
 	}
 }
 
+// Unregister removes a protocol and the aliases pointing at it
+func (r *Registry) Unregister(name string) {
+	delete(r.protocols, name)
+	for alias, target := range r.aliases {
+		if target == name {
+			delete(r.aliases, alias)
+		}
+	}
+}
+
 // RegisterAlias adds an alias for an existing protocol
 func (r *Registry) RegisterAlias(alias, protocolName string) {
 	r.aliases[alias] = protocolName
@@ -170,6 +245,31 @@ func (r *Registry) AllNames() []string {
 	return names
 }
 
+// ProtocolInfo describes a registered protocol and the games it supports
+type ProtocolInfo struct {
+	Name             string       `json:"name"`
+	DefaultPort      int          `json:"default_port"`
+	DefaultQueryPort int          `json:"default_query_port"`
+	Games            []GameConfig `json:"games"`
+}
+
+// List returns metadata for all registered protocols, sorted by name with each protocol's games sorted by name
+func (r *Registry) List() []ProtocolInfo {
+	infos := make([]ProtocolInfo, 0, len(r.protocols))
+	for _, protocol := range r.protocols {
+		games := append([]GameConfig(nil), protocol.Games()...)
+		sort.Slice(games, func(i, j int) bool { return games[i].Name < games[j].Name })
+		infos = append(infos, ProtocolInfo{
+			Name:             protocol.Name(),
+			DefaultPort:      protocol.DefaultPort(),
+			DefaultQueryPort: protocol.DefaultQueryPort(),
+			Games:            games,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
 // GetProtocol retrieves a protocol by name from the global registry
 func GetProtocol(name string) (Protocol, bool) {
 	return registry.Get(name)
@@ -185,11 +285,40 @@ func AllProtocols() map[string]Protocol {
 	return registry.All()
 }
 
+// AllProtocolsSorted returns all registered protocols ordered by name, for callers that
+// try protocols in sequence and need the same order on every run
+func AllProtocolsSorted() []Protocol {
+	all := registry.All()
+	protocols := make([]Protocol, 0, len(all))
+	for _, protocol := range all {
+		protocols = append(protocols, protocol)
+	}
+	sort.Slice(protocols, func(i, j int) bool { return protocols[i].Name() < protocols[j].Name() })
+	return protocols
+}
+
+// ListProtocols returns metadata for all registered protocols from the global registry
+func ListProtocols() []ProtocolInfo {
+	return registry.List()
+}
+
 // AllGameNames returns all game names including aliases
 func AllGameNames() []string {
 	return registry.AllNames()
 }
 
+// Register adds a protocol to the global registry, making it available by name and to
+// auto-detection. The registry is not safe for concurrent use, so register protocols from
+// init or before the first query.
+func Register(protocol Protocol) {
+	registry.Register(protocol)
+}
+
+// Unregister removes a protocol added with Register, under the same concurrency rules
+func Unregister(name string) {
+	registry.Unregister(name)
+}
+
 // RegisterAlias adds an alias for an existing protocol
 func RegisterAlias(alias, protocolName string) {
 	registry.RegisterAlias(alias, protocolName)
@@ -198,6 +327,84 @@ func RegisterAlias(alias, protocolName string) {
 // Constants for discovery mode
 const DiscoveryTimeout = 300 * time.Millisecond
 
+// retryBackoff is the delay before the first retry, doubled on each further attempt
+const retryBackoff = 100 * time.Millisecond
+
+// IsConnectionRefused reports whether err was caused by the host actively refusing the
+// connection, a TCP reset on connect or an ICMP port unreachable on a UDP read
+func IsConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryableError marks failures that are worth another attempt, such as a connection
+// reset after a successful connect
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// applyPingSamples sets info.Ping to the median of the samples and records their spread in
+// Extra["ping_min"] and Extra["ping_max"]
+func applyPingSamples(info *ServerInfo, samples []int) {
+	if len(samples) == 0 {
+		return
+	}
+	sorted := append([]int(nil), samples...)
+	sort.Ints(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		info.Ping = (sorted[middle-1] + sorted[middle]) / 2
+	} else {
+		info.Ping = sorted[middle]
+	}
+
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["ping_min"] = strconv.Itoa(sorted[0])
+	info.Extra["ping_max"] = strconv.Itoa(sorted[len(sorted)-1])
+}
+
+// defaultRawResponseSize fits any single UDP datagram
+const defaultRawResponseSize = 65535
+
+// RawExchange dials addr with the usual connection setup, writes payload and returns the
+// first response read, up to opts.MaxResponseSize bytes. For UDP that is one datagram,
+// for TCP whatever arrives in the first read.
+func RawExchange(ctx context.Context, network, addr string, payload []byte, opts *Options) ([]byte, error) {
+	conn, err := setupConnection(ctx, network, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(payload); err != nil {
+		return nil, fmt.Errorf("write failed: %w", err)
+	}
+
+	size := opts.MaxResponseSize
+	if size <= 0 {
+		size = defaultRawResponseSize
+	}
+	response := make([]byte, size)
+	n, err := conn.Read(response)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	if opts.Debug {
+		debugLogf(opts, "Raw", "Received %d bytes from %s://%s", n, network, addr)
+	}
+	return response[:n], nil
+}
+
 // getTimeout returns the appropriate timeout based on discovery mode
 func getTimeout(opts *Options) time.Duration {
 	if opts.DiscoveryMode {
@@ -206,29 +413,162 @@ func getTimeout(opts *Options) time.Duration {
 	return opts.Timeout
 }
 
+// getHTTPTimeout returns the timeout for HTTP requests, falling back to getTimeout
+func getHTTPTimeout(opts *Options) time.Duration {
+	if opts.HTTPTimeout > 0 {
+		return opts.HTTPTimeout
+	}
+	return getTimeout(opts)
+}
+
+// httpSchemes returns the URL schemes HTTP-based protocols try in order: HTTPS first when
+// a TLS config is set, otherwise plain HTTP with HTTPS as the fallback
+func httpSchemes(opts *Options) []string {
+	if opts.TLSConfig != nil {
+		return []string{"https", "http"}
+	}
+	return []string{"http", "https"}
+}
+
+// httpRedirectPolicy is the CheckRedirect of HTTP-based protocols. Game server APIs are
+// queried directly, and following redirects from an untrusted server would let it point
+// queries at internal endpoints, so past opts.MaxRedirects the redirect itself is returned,
+// which getHTTP reports as a status error.
+func httpRedirectPolicy(opts *Options) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > opts.MaxRedirects {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// getHTTP sends the request built by newRequest for each scheme from httpSchemes in turn and
+// returns the first 200 response. The next scheme is only tried when the server answered but
+// rejected the request, such as plain HTTP sent to a TLS port, so a refused or silent port
+// costs a single attempt. The error of the preferred scheme is returned when all fail.
+func getHTTP(ctx context.Context, client *http.Client, opts *Options, newRequest func(scheme string) (*http.Request, error)) (*http.Response, error) {
+	var firstErr error
+	for _, scheme := range httpSchemes(opts) {
+		req, err := newRequest(scheme)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			err = &httpStatusError{code: resp.StatusCode, status: resp.Status}
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		var netErr net.Error
+		if IsConnectionRefused(err) || ctx.Err() != nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// httpStatusError reports an HTTP response other than 200 OK
+type httpStatusError struct {
+	code   int
+	status string
+}
+
+func (e *httpStatusError) Error() string {
+	return "unexpected status: " + e.status
+}
+
+// isTransientHTTPError reports whether an HTTP failure may succeed on retry: a server
+// error or a timeout, as opposed to a missing endpoint or a refused connection
+func isTransientHTTPError(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// defaultMaxStreamSize bounds responses read from TCP streams and HTTP bodies, where the
+// server sets the length and an absurd one could otherwise exhaust memory
+const defaultMaxStreamSize = 2 << 20
+
+// errResponseTooLarge reports a response whose advertised or actual size exceeds the cap
+var errResponseTooLarge = errors.New("response exceeds size limit")
+
+// getMaxStreamSize returns the cap for stream responses, falling back to defaultMaxStreamSize
+func getMaxStreamSize(opts *Options) int {
+	if opts.MaxResponseSize > 0 {
+		return opts.MaxResponseSize
+	}
+	return defaultMaxStreamSize
+}
+
 // setupConnection handles common connection setup with discovery mode timeout
 func setupConnection(ctx context.Context, network, addr string, opts *Options) (net.Conn, error) {
 	timeout := getTimeout(opts)
 
 	if opts.Debug {
-		debugLogf("Connection", "Connecting to %s://%s with timeout %v (discovery mode: %v)",
+		debugLogf(opts, "Connection", "Connecting to %s://%s with timeout %v (discovery mode: %v)",
 			network, addr, timeout, opts.DiscoveryMode)
 	}
 
+	resolveStart := time.Now()
+	dialAddrs, err := resolveDialAddrs(ctx, addr, opts)
+	resolveElapsed := time.Since(resolveStart)
+	if err != nil {
+		if opts.Debug {
+			debugLogf(opts, "Connection", "Resolving %s FAILED: %v", addr, err)
+		}
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+
 	start := time.Now()
-	dialer := &net.Dialer{Timeout: timeout}
-	conn, err := dialer.DialContext(ctx, network, addr)
+	dial := opts.Dial
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: timeout}).DialContext
+	}
+	// Like the dialer itself, fall back to the other addresses of a host with several, such
+	// as one that lists IPv6 first on a network without an IPv6 route. As in net.Dialer,
+	// the timeout is shared: each attempt gets an equal part of what is left of it.
+	var conn net.Conn
+	dialDeadline := start.Add(timeout)
+	for i, dialAddr := range dialAddrs {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if len(dialAddrs) > 1 {
+			share := time.Until(dialDeadline) / time.Duration(len(dialAddrs)-i)
+			attemptCtx, cancel = context.WithTimeout(ctx, share)
+		}
+		conn, err = dial(attemptCtx, network, dialAddr)
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		if opts.Debug && len(dialAddrs) > 1 {
+			debugLogf(opts, "Connection", "Connection to %s://%s FAILED, trying next address: %v", network, dialAddr, err)
+		}
+	}
 	elapsed := time.Since(start)
 
 	if err != nil {
 		if opts.Debug {
-			debugLogf("Connection", "Connection to %s://%s FAILED: %v (took %v)", network, addr, err, elapsed)
+			debugLogf(opts, "Connection", "Connection to %s://%s FAILED: %v (took %v)", network, addr, err, elapsed)
 		}
 		return nil, fmt.Errorf("connection failed: %w", err)
 	}
 
 	if opts.Debug {
-		debugLogf("Connection", "Connection to %s://%s successful (took %v)", network, addr, elapsed)
+		debugLogf(opts, "Connection", "Connection to %s://%s successful (took %v)", network, addr, elapsed)
+	}
+	if opts.Timings != nil {
+		opts.Timings.record(resolveElapsed, elapsed)
 	}
 
 	// Set deadline based on context or timeout
@@ -239,18 +579,84 @@ func setupConnection(ctx context.Context, network, addr string, opts *Options) (
 	conn.SetDeadline(deadline)
 
 	if opts.Debug {
-		debugLogf("Connection", "Set deadline for %s://%s to %v", network, addr, deadline)
+		debugLogf(opts, "Connection", "Set deadline for %s://%s to %v", network, addr, deadline)
 	}
 
 	return conn, nil
 }
 
-// Debug logging helpers
-func debugLog(component, message string) {
+// resolveDialAddrs resolves the host in addr through opts.LookupHost when set, returning
+// every address to dial in order. Without it the dialer resolves the host itself, unless
+// timings are requested, in which case the system resolver is called here so the lookup
+// can be timed.
+func resolveDialAddrs(ctx context.Context, addr string, opts *Options) ([]string, error) {
+	lookup := opts.LookupHost
+	if lookup == nil && opts.Timings != nil && opts.Dial == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	if lookup == nil {
+		return []string{addr}, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return []string{addr}, nil
+	}
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	dialAddrs := make([]string, len(addrs))
+	for i, resolved := range addrs {
+		dialAddrs[i] = net.JoinHostPort(resolved, port)
+	}
+	return dialAddrs, nil
+}
+
+// Timings records how long the first connection of a query spent resolving and connecting
+type Timings struct {
+	mu       sync.Mutex
+	recorded bool
+	dns      time.Duration
+	connect  time.Duration
+}
+
+// record keeps the first connection's durations; later connections such as sub-queries are ignored
+func (t *Timings) record(dns, connect time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.recorded {
+		t.recorded = true
+		t.dns, t.connect = dns, connect
+	}
+}
+
+// Durations returns the recorded DNS and connect durations
+func (t *Timings) Durations() (dns, connect time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dns, t.connect
+}
+
+// Debug logging helpers, routed to opts.Logger when set and stderr otherwise
+func debugLog(opts *Options, component, message string) {
+	if opts.Logger != nil {
+		if opts.QueryID != "" {
+			opts.Logger.Debug(message, "component", component, "query_id", opts.QueryID, "target", opts.QueryTarget)
+			return
+		}
+		opts.Logger.Debug(message, "component", component)
+		return
+	}
+	if opts.QueryID != "" {
+		component = fmt.Sprintf("%s (%s %s)", component, opts.QueryID, opts.QueryTarget)
+	}
 	fmt.Fprintf(os.Stderr, "[DEBUG %s] %s: %s\n", time.Now().Format("15:04:05.000"), component, message)
 }
 
-func debugLogf(component, format string, args ...interface{}) {
+func debugLogf(opts *Options, component, format string, args ...interface{}) {
 	message := fmt.Sprintf(format, args...)
-	debugLog(component, message)
+	debugLog(opts, component, message)
 }
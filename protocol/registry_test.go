@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListProtocols(t *testing.T) {
+	infos := ListProtocols()
+
+	assert.Len(t, infos, len(AllProtocols()))
+	assert.True(t, sort.SliceIsSorted(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name }))
+
+	for _, info := range infos {
+		proto, exists := GetProtocol(info.Name)
+		assert.True(t, exists)
+		assert.Equal(t, proto.DefaultPort(), info.DefaultPort)
+		assert.Equal(t, proto.DefaultQueryPort(), info.DefaultQueryPort)
+		assert.ElementsMatch(t, proto.Games(), info.Games)
+	}
+}
+
+func TestAllProtocolsSorted(t *testing.T) {
+	protocols := AllProtocolsSorted()
+	assert.Len(t, protocols, len(AllProtocols()))
+	for i := 1; i < len(protocols); i++ {
+		assert.Less(t, protocols[i-1].Name(), protocols[i].Name())
+	}
+}
+
+func TestPlayer_JoinedAt(t *testing.T) {
+	queriedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	player := Player{Name: "Alice", Duration: 90*time.Minute + 500*time.Millisecond}
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 29, 59, int(500*time.Millisecond), time.UTC), player.JoinedAt(queriedAt))
+
+	// Protocols without durations give no estimate
+	assert.True(t, Player{Name: "Bob"}.JoinedAt(queriedAt).IsZero())
+}
+
+func TestSetupConnection_FallsBackToNextAddress(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	var dialed []string
+	opts := &Options{
+		Timeout: 2 * time.Second,
+		LookupHost: func(ctx context.Context, host string) ([]string, error) {
+			return []string{"2001:db8::1", "127.0.0.1"}, nil
+		},
+		// The IPv6 address has no route, as on an IPv4-only network
+		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			if host, _, _ := net.SplitHostPort(addr); host != "127.0.0.1" {
+				return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("network is unreachable")}
+			}
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+
+	conn, err := setupConnection(context.Background(), "tcp", net.JoinHostPort("game.example.com", port), opts)
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+	assert.Equal(t, []string{net.JoinHostPort("2001:db8::1", port), net.JoinHostPort("127.0.0.1", port)}, dialed)
+
+	// With no address reachable the last error is reported
+	opts.LookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"2001:db8::1", "2001:db8::2"}, nil
+	}
+	_, err = setupConnection(context.Background(), "tcp", net.JoinHostPort("game.example.com", port), opts)
+	assert.ErrorContains(t, err, "network is unreachable")
+}
+
+func TestSetupConnection_SharesTimeoutAcrossAddresses(t *testing.T) {
+	opts := &Options{
+		Timeout: 300 * time.Millisecond,
+		LookupHost: func(ctx context.Context, host string) ([]string, error) {
+			return []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, nil
+		},
+		// Every address is blackholed, so each attempt lasts until its deadline
+		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	start := time.Now()
+	_, err := setupConnection(context.Background(), "tcp", "game.example.com:27015", opts)
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 450*time.Millisecond)
+}
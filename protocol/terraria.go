@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"net"
 	"net/http"
@@ -24,6 +25,10 @@ func (t *TerrariaProtocol) Name() string {
 	return "terraria"
 }
 
+func (t *TerrariaProtocol) Network() string {
+	return "tcp"
+}
+
 func (t *TerrariaProtocol) DefaultPort() int {
 	return 7777
 }
@@ -44,7 +49,7 @@ func (t *TerrariaProtocol) DetectGame(info *ServerInfo) string {
 
 func (t *TerrariaProtocol) Query(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
 	if opts.Debug {
-		debugLogf("Terraria", "Starting query for %s", addr)
+		debugLogf(opts, "Terraria", "Starting query for %s", addr)
 	}
 	
 	conn, err := setupConnection(ctx, "tcp", addr, opts)
@@ -55,22 +60,22 @@ func (t *TerrariaProtocol) Query(ctx context.Context, addr string, opts *Options
 
 	// Try TShock REST API first (more reliable)
 	if opts.Debug {
-		debugLog("Terraria", "Trying TShock REST API first")
+		debugLog(opts, "Terraria", "Trying TShock REST API first")
 	}
 	tshockStart := time.Now()
-	if info, err := t.queryTShockAPI(ctx, addr, getTimeout(opts)); err == nil {
+	if info, err := t.queryTShockAPI(ctx, addr, opts); err == nil {
 		info.Ping = int(math.Ceil(float64(time.Since(tshockStart).Nanoseconds()) / 1e6))
 		if opts.Debug {
-			debugLog("Terraria", "TShock API query successful")
+			debugLog(opts, "Terraria", "TShock API query successful")
 		}
 		return info, nil
 	} else if opts.Debug {
-		debugLogf("Terraria", "TShock API query failed: %v", err)
+		debugLogf(opts, "Terraria", "TShock API query failed: %v", err)
 	}
 
 	// Fallback to native protocol
 	if opts.Debug {
-		debugLog("Terraria", "Fallback to native TCP protocol")
+		debugLog(opts, "Terraria", "Fallback to native TCP protocol")
 	}
 	
 	// Send server info request packet
@@ -80,7 +85,7 @@ func (t *TerrariaProtocol) Query(ctx context.Context, addr string, opts *Options
 	}
 
 	if opts.Debug {
-		debugLogf("Terraria", "Sending server info request (%d bytes)", len(serverInfoPacket))
+		debugLogf(opts, "Terraria", "Sending server info request (%d bytes)", len(serverInfoPacket))
 	}
 
 	// Measure ping from request send to response receive
@@ -88,7 +93,7 @@ func (t *TerrariaProtocol) Query(ctx context.Context, addr string, opts *Options
 	
 	if _, err := conn.Write(serverInfoPacket); err != nil {
 		if opts.Debug {
-			debugLogf("Terraria", "Write failed: %v", err)
+			debugLogf(opts, "Terraria", "Write failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("write server info request failed: %w", err)
 	}
@@ -101,31 +106,55 @@ func (t *TerrariaProtocol) Query(ctx context.Context, addr string, opts *Options
 	
 	if err != nil {
 		if opts.Debug {
-			debugLogf("Terraria", "Read failed: %v", err)
+			debugLogf(opts, "Terraria", "Read failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("read failed: %w", err)
 	}
 
 	if opts.Debug {
-		debugLogf("Terraria", "Received %d bytes response (ping: %dms)", n, ping)
+		debugLogf(opts, "Terraria", "Received %d bytes response (ping: %dms)", n, ping)
 	}
 
 	// Parse whatever response we get
 	info, err := t.parseResponse(response[:n])
 	if err != nil {
 		if opts.Debug {
-			debugLogf("Terraria", "Response parsing failed: %v", err)
+			debugLogf(opts, "Terraria", "Response parsing failed: %v", err)
 		}
 		return &ServerInfo{Online: false}, fmt.Errorf("parse failed: %w", err)
 	}
 
 	info.Ping = ping
 	if opts.Debug {
-		debugLog("Terraria", "Query completed successfully")
+		debugLog(opts, "Terraria", "Query completed successfully")
 	}
 	return info, nil
 }
 
+// Probe sends the native server info request and accepts any packet in response
+func (t *TerrariaProtocol) Probe(ctx context.Context, addr string, opts *Options) error {
+	conn, err := setupConnection(ctx, "tcp", addr, opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	serverInfoPacket := []byte{0x05, 0x00, 0x00, 0x00, 0x01}
+	if _, err := conn.Write(serverInfoPacket); err != nil {
+		return fmt.Errorf("write server info request failed: %w", err)
+	}
+
+	response := make([]byte, 1024)
+	n, err := conn.Read(response)
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if n < 5 {
+		return fmt.Errorf("response too short")
+	}
+	return nil
+}
+
 func (t *TerrariaProtocol) parseResponse(data []byte) (*ServerInfo, error) {
 	if len(data) < 5 {
 		return nil, fmt.Errorf("response too short")
@@ -269,8 +298,12 @@ func (t *TerrariaProtocol) parseResponse(data []byte) (*ServerInfo, error) {
 	return info, nil
 }
 
-// queryTShockAPI attempts to query TShock REST API
-func (t *TerrariaProtocol) queryTShockAPI(ctx context.Context, addr string, timeout time.Duration) (*ServerInfo, error) {
+// tshockRESTPort is the port of the TShock REST API, a variable so tests can point it at a mock
+var tshockRESTPort = 7878
+
+// queryTShockAPI attempts to query TShock REST API, reading at most the maximum stream size of each body.
+// A refused connection means the API is disabled, so the remaining endpoints are skipped.
+func (t *TerrariaProtocol) queryTShockAPI(ctx context.Context, addr string, opts *Options) (*ServerInfo, error) {
 	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid address: %w", err)
@@ -282,55 +315,86 @@ func (t *TerrariaProtocol) queryTShockAPI(ctx context.Context, addr string, time
 	}
 
 	// TShock REST API is typically on port 7878
-	restPort := 7878
+	restAddr := net.JoinHostPort(host, strconv.Itoa(tshockRESTPort))
 	
 	// Try common TShock REST API endpoints
 	endpoints := []string{
-		fmt.Sprintf("http://%s:%d/v2/server/status", host, restPort),
-		fmt.Sprintf("http://%s:%d/status", host, restPort),
-		fmt.Sprintf("http://%s:%d/v3/server/status", host, restPort),
+		"/v2/server/status",
+		"/status",
+		"/v3/server/status",
 	}
 
-	client := &http.Client{Timeout: timeout}
+	client := &http.Client{Timeout: getHTTPTimeout(opts), CheckRedirect: httpRedirectPolicy(opts)}
+	if opts.Dial != nil || opts.TLSConfig != nil {
+		client.Transport = &http.Transport{DialContext: opts.Dial, TLSClientConfig: opts.TLSConfig}
+	}
 
 	for _, endpoint := range endpoints {
-		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		tshockStatus, err := t.getTShockStatus(ctx, client, restAddr+endpoint, opts)
 		if err != nil {
+			if IsConnectionRefused(err) {
+				return nil, fmt.Errorf("TShock API disabled: %w", err)
+			}
 			continue
 		}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
+		return &ServerInfo{
+			Name:    tshockStatus.Name,
+			Version: tshockStatus.TerrariaVersion,
+			Online:  true,
+			Players: PlayerInfo{
+				Current: tshockStatus.PlayerCount,
+				Max:     tshockStatus.MaxPlayers,
+				List:    make([]Player, 0),
+			},
+			Game: "terraria",
+			Extra: map[string]string{
+				"world":      tshockStatus.World,
+				"tshock":     tshockStatus.TShockVersion,
+				"difficulty": strconv.Itoa(tshockStatus.Difficulty),
+			},
+		}, nil
+	}
 
-		if resp.StatusCode == http.StatusOK {
-			var tshockStatus TShockStatus
-			if err := json.NewDecoder(resp.Body).Decode(&tshockStatus); err != nil {
-				continue
-			}
+	return nil, fmt.Errorf("TShock API not available")
+}
 
-			return &ServerInfo{
-				Name:    tshockStatus.Name,
-				Version: tshockStatus.TerrariaVersion,
-				Online:  true,
-				Players: PlayerInfo{
-					Current: tshockStatus.PlayerCount,
-					Max:     tshockStatus.MaxPlayers,
-					List:    make([]Player, 0),
-				},
-				Game: "terraria",
-				Extra: map[string]string{
-					"world":      tshockStatus.World,
-					"tshock":     tshockStatus.TShockVersion,
-					"difficulty": strconv.Itoa(tshockStatus.Difficulty),
-				},
-			}, nil
+// getTShockStatus fetches one status endpoint (host:port/path). When retries are enabled a
+// transient failure, a server error or timeout, is retried once after a backoff.
+func (t *TerrariaProtocol) getTShockStatus(ctx context.Context, client *http.Client, endpoint string, opts *Options) (*TShockStatus, error) {
+	retries := min(opts.Retries, 1)
+	for attempt := 0; ; attempt++ {
+		tshockStatus, err := t.fetchTShockStatus(ctx, client, endpoint, opts)
+		if err == nil || attempt >= retries || !isTransientHTTPError(err) {
+			return tshockStatus, err
 		}
+
+		if opts.Debug {
+			debugLogf(opts, "Terraria", "TShock request to %s failed: %v, retrying in %v", endpoint, err, retryBackoff)
+		}
+		select {
+		case <-time.After(retryBackoff):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// fetchTShockStatus requests and decodes one status endpoint
+func (t *TerrariaProtocol) fetchTShockStatus(ctx context.Context, client *http.Client, endpoint string, opts *Options) (*TShockStatus, error) {
+	resp, err := getHTTP(ctx, client, opts, func(scheme string) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", scheme+"://"+endpoint, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	return nil, fmt.Errorf("TShock API not available")
+	var tshockStatus TShockStatus
+	if err := json.NewDecoder(io.LimitReader(resp.Body, int64(getMaxStreamSize(opts)))).Decode(&tshockStatus); err != nil {
+		return nil, err
+	}
+	return &tshockStatus, nil
 }
 
 // TShockStatus represents TShock REST API response
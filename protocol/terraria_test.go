@@ -0,0 +1,95 @@
+package protocol
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startMockTShockServer serves only the /v2/server/status endpoint, failing the first
+// failures requests to it with 503, and points tshockRESTPort at it for the duration of the test.
+func startMockTShockServer(t *testing.T, failures int32) *atomic.Int32 {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/server/status" {
+			http.NotFound(w, r)
+			return
+		}
+		if requests.Add(1) <= failures {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"name":"TShock Server","world":"Hallow","playercount":3,"maxplayers":16,` +
+			`"terraria_version":"1.4.4.9","tshock_version":"5.2.0","difficulty":1}`))
+	}))
+	t.Cleanup(server.Close)
+	setTShockRESTPort(t, server.Listener.Addr().(*net.TCPAddr).Port)
+	return &requests
+}
+
+// setTShockRESTPort points the TShock REST port at port until the test ends.
+func setTShockRESTPort(t *testing.T, port int) {
+	original := tshockRESTPort
+	tshockRESTPort = port
+	t.Cleanup(func() { tshockRESTPort = original })
+}
+
+func TestTerrariaProtocol_QueryTShockAPI(t *testing.T) {
+	startMockTShockServer(t, 0)
+
+	protocol := &TerrariaProtocol{}
+	info, err := protocol.queryTShockAPI(context.Background(), "127.0.0.1:7777", &Options{Timeout: 2 * time.Second})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TShock Server", info.Name)
+	assert.Equal(t, "1.4.4.9", info.Version)
+	assert.Equal(t, 3, info.Players.Current)
+	assert.Equal(t, "Hallow", info.Extra["world"])
+}
+
+func TestTerrariaProtocol_QueryTShockAPI_RetriesTransientError(t *testing.T) {
+	requests := startMockTShockServer(t, 1)
+
+	protocol := &TerrariaProtocol{}
+	info, err := protocol.queryTShockAPI(context.Background(), "127.0.0.1:7777", &Options{Timeout: 2 * time.Second, Retries: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TShock Server", info.Name)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestTerrariaProtocol_QueryTShockAPI_NoRetryWithoutOption(t *testing.T) {
+	requests := startMockTShockServer(t, 1)
+
+	protocol := &TerrariaProtocol{}
+	_, err := protocol.queryTShockAPI(context.Background(), "127.0.0.1:7777", &Options{Timeout: 2 * time.Second})
+
+	// The 503 moves on to the other endpoints, which don't exist
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestTerrariaProtocol_QueryTShockAPI_RefusedFallsBackImmediately(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	setTShockRESTPort(t, port)
+
+	protocol := &TerrariaProtocol{}
+	start := time.Now()
+	_, err = protocol.queryTShockAPI(context.Background(), net.JoinHostPort("127.0.0.1", strconv.Itoa(7777)), &Options{Timeout: 2 * time.Second, Retries: 3})
+
+	assert.ErrorContains(t, err, "disabled")
+	assert.True(t, IsConnectionRefused(err))
+	assert.Less(t, time.Since(start), retryBackoff)
+}
@@ -0,0 +1,38 @@
+package query
+
+// adjacentPorts returns port+offset for each offset in order, dropping ports outside the
+// valid range and repeats
+func adjacentPorts(port int, offsets []int) []int {
+	seen := map[int]bool{port: true}
+	var ports []int
+	for _, offset := range offsets {
+		candidate := port + offset
+		if candidate < 1 || candidate > 65535 || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		ports = append(ports, candidate)
+	}
+	return ports
+}
+
+// WithAdjacentPortRange makes Query try the n ports either side of the given port, nearest
+// first, when the port itself doesn't answer, before falling back to the common ports.
+// Servers often put the query port a step or two from the game port.
+func WithAdjacentPortRange(n int) Option {
+	return func(o *QueryOptions) {
+		o.AdjacentPortOffsets = nil
+		for i := 1; i <= n; i++ {
+			o.AdjacentPortOffsets = append(o.AdjacentPortOffsets, i, -i)
+		}
+	}
+}
+
+// WithAdjacentPortOffsets makes Query try port+offset for each offset, in order, when the
+// given port doesn't answer, e.g. []int{10} for a deployment whose query port is the game
+// port plus 10
+func WithAdjacentPortOffsets(offsets []int) Option {
+	return func(o *QueryOptions) {
+		o.AdjacentPortOffsets = offsets
+	}
+}
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjacentPorts(t *testing.T) {
+	assert.Nil(t, adjacentPorts(27015, nil))
+	assert.Equal(t, []int{27016, 27014, 27017, 27013}, adjacentPorts(27015, []int{1, -1, 2, -2}))
+	// Out of range ports, the port itself and repeats are dropped
+	assert.Equal(t, []int{2, 11}, adjacentPorts(1, []int{-1, 1, 0, 1, 10}))
+	assert.Equal(t, []int{65534}, adjacentPorts(65535, []int{1, -1}))
+}
+
+func TestWithAdjacentPortRange(t *testing.T) {
+	options := &QueryOptions{}
+	WithAdjacentPortRange(2)(options)
+	assert.Equal(t, []int{1, -1, 2, -2}, options.AdjacentPortOffsets)
+}
+
+func TestQuery_AdjacentPortOffsets(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	port := startA2SResponder(t, "Offset Server", 440)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port-10))
+
+	info, err := Query(context.Background(), addr, WithAdjacentPortOffsets([]int{10}))
+	assert.NoError(t, err)
+	if assert.NotNil(t, info) {
+		assert.Equal(t, "Offset Server", info.Name)
+		assert.Equal(t, port, info.QueryPort)
+	}
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// Check validates one aspect of a query result for Assert
+type Check func(info *protocol.ServerInfo) error
+
+// CheckError reports a failed check
+type CheckError struct {
+	Check    string
+	Expected string
+	Actual   string
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("%s: expected %s, got %s", e.Check, e.Expected, e.Actual)
+}
+
+// Assert runs every check against info and returns all failures joined, or nil when all pass.
+// Use errors.As to inspect individual *CheckError values.
+func Assert(info *protocol.ServerInfo, checks ...Check) error {
+	if info == nil || !info.Online {
+		return &CheckError{Check: "online", Expected: "online server", Actual: "offline"}
+	}
+
+	var failures []error
+	for _, check := range checks {
+		if err := check(info); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// MinPlayers requires at least n players online
+func MinPlayers(n int) Check {
+	return func(info *protocol.ServerInfo) error {
+		if info.Players.Current < n {
+			return &CheckError{Check: "min players", Expected: fmt.Sprintf("at least %d", n), Actual: fmt.Sprintf("%d", info.Players.Current)}
+		}
+		return nil
+	}
+}
+
+// GameIs requires the detected game to be name
+func GameIs(name string) Check {
+	return func(info *protocol.ServerInfo) error {
+		if info.Game != name {
+			return &CheckError{Check: "game", Expected: name, Actual: info.Game}
+		}
+		return nil
+	}
+}
+
+// MaxPingMs requires a ping of at most ms milliseconds
+func MaxPingMs(ms int) Check {
+	return func(info *protocol.ServerInfo) error {
+		if info.Ping > ms {
+			return &CheckError{Check: "max ping", Expected: fmt.Sprintf("at most %dms", ms), Actual: fmt.Sprintf("%dms", info.Ping)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssert(t *testing.T) {
+	info := &protocol.ServerInfo{
+		Game:    "rust",
+		Online:  true,
+		Ping:    40,
+		Players: protocol.PlayerInfo{Current: 12, Max: 100},
+	}
+
+	assert.NoError(t, Assert(info, MinPlayers(10), GameIs("rust"), MaxPingMs(50)))
+
+	err := Assert(info, MinPlayers(20), GameIs("rust"), MaxPingMs(30))
+	assert.ErrorContains(t, err, "min players: expected at least 20, got 12")
+	assert.ErrorContains(t, err, "max ping: expected at most 30ms, got 40ms")
+	assert.NotContains(t, err.Error(), "game")
+
+	var checkErr *CheckError
+	assert.True(t, errors.As(err, &checkErr))
+	assert.Equal(t, "min players", checkErr.Check)
+}
+
+func TestAssert_Offline(t *testing.T) {
+	var checkErr *CheckError
+
+	err := Assert(nil, MinPlayers(0))
+	assert.True(t, errors.As(err, &checkErr))
+	assert.Equal(t, "online", checkErr.Check)
+
+	err = Assert(&protocol.ServerInfo{Online: false})
+	assert.Error(t, err)
+}
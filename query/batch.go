@@ -0,0 +1,116 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// QueryBatch queries each address concurrently with the same options as Query and returns
+// one result per address in input order. Unlike Query, failures are not returned as errors:
+// an address that could not be queried yields an offline ServerInfo with Address and Port
+// set and the failure in Extra["error"]. WithOnlineOnly drops those offline entries.
+func QueryBatch(ctx context.Context, addrs []string, opts ...Option) []*protocol.ServerInfo {
+	options := batchOptions(opts)
+
+	results := make([]*protocol.ServerInfo, len(addrs))
+	runBatch(ctx, addrs, options, opts, func(i int, info *protocol.ServerInfo) {
+		results[i] = info
+	})
+
+	if options.OnlineOnly {
+		results = filterOnline(results)
+	}
+	return results
+}
+
+// QueryBatchStream is QueryBatch sending each result to out as soon as its query completes,
+// in completion order rather than input order. It blocks until every address is done and
+// then closes out, so run it in its own goroutine. Once ctx is done, results the caller has
+// not received yet may be dropped so an abandoned channel doesn't block the batch.
+func QueryBatchStream(ctx context.Context, addrs []string, out chan<- *protocol.ServerInfo, opts ...Option) {
+	defer close(out)
+	options := batchOptions(opts)
+
+	runBatch(ctx, addrs, options, opts, func(i int, info *protocol.ServerInfo) {
+		if options.OnlineOnly && !info.Online {
+			return
+		}
+		select {
+		case out <- info:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// batchOptions applies opts over the batch defaults
+func batchOptions(opts []Option) *QueryOptions {
+	options := &QueryOptions{
+		Timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// runBatch queries addrs concurrently and calls emit from the querying goroutine with each
+// address's index and result, an offline entry when the query failed
+func runBatch(ctx context.Context, addrs []string, options *QueryOptions, opts []Option, emit func(i int, info *protocol.ServerInfo)) {
+	// Set up concurrency
+	maxConcurrency := options.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = autoConcurrency(len(addrs))
+	}
+	semaphore := make(chan struct{}, maxConcurrency)
+
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			info, err := Query(ctx, addr, opts...)
+			if err != nil {
+				info = offlineServerInfo(addr, options.Port, err)
+			}
+			emit(i, info)
+		}(i, addr)
+	}
+	wg.Wait()
+}
+
+// offlineServerInfo describes an address that could not be queried
+func offlineServerInfo(addr string, optPort int, err error) *protocol.ServerInfo {
+	info := &protocol.ServerInfo{
+		Address:   addr,
+		Online:    false,
+		QueriedAt: time.Now(),
+		Extra:     map[string]string{"error": err.Error(), "error_class": ClassifyError(err).String()},
+	}
+	if errors.Is(err, ErrPossiblyRelayOnly) {
+		info.Extra["possibly_relay_only"] = "true"
+	}
+	if host, port, parseErr := parseAddress(addr, optPort); parseErr == nil {
+		info.Address = host
+		info.Port = port
+		info.QueryPort = port
+	}
+	return info
+}
+
+// filterOnline returns the online entries of servers, preserving order
+func filterOnline(servers []*protocol.ServerInfo) []*protocol.ServerInfo {
+	online := make([]*protocol.ServerInfo, 0, len(servers))
+	for _, info := range servers {
+		if info != nil && info.Online {
+			online = append(online, info)
+		}
+	}
+	return online
+}
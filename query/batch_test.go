@@ -0,0 +1,118 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// closedPort returns a local port with nothing listening on it.
+func closedPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestQueryBatch(t *testing.T) {
+	// Keep auto-detection on the given ports only
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	onlinePort := startA2SResponder(t, "Batch Server", 730)
+	offlinePort := closedPort(t)
+	addrs := []string{
+		"127.0.0.1:" + strconv.Itoa(offlinePort),
+		"127.0.0.1:" + strconv.Itoa(onlinePort),
+	}
+
+	results := QueryBatch(context.Background(), addrs, WithTimeout(500*time.Millisecond))
+
+	assert.Len(t, results, 2)
+	assert.False(t, results[0].Online)
+	assert.Equal(t, "127.0.0.1", results[0].Address)
+	assert.Equal(t, offlinePort, results[0].Port)
+	assert.NotEmpty(t, results[0].Extra["error"])
+	assert.True(t, results[1].Online)
+	assert.Equal(t, "Batch Server", results[1].Name)
+
+	online := QueryBatch(context.Background(), addrs, WithTimeout(500*time.Millisecond), WithOnlineOnly())
+
+	assert.Len(t, online, 1)
+	assert.Equal(t, "Batch Server", online[0].Name)
+}
+
+func TestQueryBatchStream(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	addrs := []string{
+		"127.0.0.1:" + strconv.Itoa(closedPort(t)),
+		"127.0.0.1:" + strconv.Itoa(startA2SResponder(t, "Stream Server 1", 730)),
+		"127.0.0.1:" + strconv.Itoa(startA2SResponder(t, "Stream Server 2", 730)),
+	}
+
+	out := make(chan *protocol.ServerInfo)
+	go QueryBatchStream(context.Background(), addrs, out, WithTimeout(500*time.Millisecond))
+
+	var names []string
+	offline := 0
+	for info := range out {
+		if info.Online {
+			names = append(names, info.Name)
+		} else {
+			offline++
+		}
+	}
+	assert.ElementsMatch(t, []string{"Stream Server 1", "Stream Server 2"}, names)
+	assert.Equal(t, 1, offline)
+
+	online := make(chan *protocol.ServerInfo)
+	go QueryBatchStream(context.Background(), addrs, online, WithTimeout(500*time.Millisecond), WithOnlineOnly())
+
+	count := 0
+	for info := range online {
+		assert.True(t, info.Online)
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestQueryBatchStream_AbandonedChannel(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	addrs := []string{
+		"127.0.0.1:" + strconv.Itoa(startA2SResponder(t, "Unread Server 1", 730)),
+		"127.0.0.1:" + strconv.Itoa(startA2SResponder(t, "Unread Server 2", 730)),
+	}
+
+	// Nobody reads the results, so cancelling must still let the batch finish
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	out := make(chan *protocol.ServerInfo)
+	done := make(chan struct{})
+	go func() {
+		QueryBatchStream(ctx, addrs, out, WithTimeout(500*time.Millisecond))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("QueryBatchStream blocked on an abandoned channel")
+	}
+	_, open := <-out
+	assert.False(t, open)
+}
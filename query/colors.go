@@ -0,0 +1,20 @@
+package query
+
+import (
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// stripColorCodes removes the color codes used by the given protocol from the server
+// and player names, keeping the original server name in info.Extra["name_raw"]
+func stripColorCodes(info *protocol.ServerInfo, protocolName string) {
+	if cleaned := protocol.StripFormatting(protocolName, info.Name); cleaned != info.Name {
+		if info.Extra == nil {
+			info.Extra = make(map[string]string)
+		}
+		info.Extra["name_raw"] = info.Name
+		info.Name = cleaned
+	}
+	for i := range info.Players.List {
+		info.Players.List[i].Name = protocol.StripFormatting(protocolName, info.Players.List[i].Name)
+	}
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripColorCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		input    string
+		expected string
+	}{
+		{"quake digits", "a2s", "^1Red ^7Server", "Red Server"},
+		{"quake hex", "a2s", "^xf00Hot^7 Server", "Hot Server"},
+		{"unity rich text", "a2s", "<color=#ff0000>EU</color> <b>Main</b>", "EU Main"},
+		{"bracketed hex", "a2s", "{#00ff00}Green [ff00ff]Pink", "Green Pink"},
+		{"control characters", "a2s", "\x04Chat\x07Color", "ChatColor"},
+		{"caret faces survive", "a2s", "^_^ Friendly", "^_^ Friendly"},
+		{"minecraft section codes", "minecraft", "§aGreen §lBold", "Green Bold"},
+		{"minecraft keeps carets", "minecraft", "Build^2", "Build^2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &protocol.ServerInfo{
+				Name:    tt.input,
+				Players: protocol.PlayerInfo{List: []protocol.Player{{Name: tt.input}}},
+			}
+
+			stripColorCodes(info, tt.protocol)
+
+			assert.Equal(t, tt.expected, info.Name)
+			assert.Equal(t, tt.expected, info.Players.List[0].Name)
+			if tt.expected != tt.input {
+				assert.Equal(t, tt.input, info.Extra["name_raw"])
+			} else {
+				assert.Nil(t, info.Extra)
+			}
+		})
+	}
+}
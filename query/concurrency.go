@@ -0,0 +1,28 @@
+package query
+
+import "runtime"
+
+// maxAutoConcurrency bounds automatic concurrency so a large scan stays well below
+// common file descriptor limits, since every in-flight query holds a socket
+const maxAutoConcurrency = 256
+
+// autoConcurrencyPerCPU is how many in-flight queries each CPU is given; queries spend
+// nearly all their time waiting on the network, so this is far above one
+const autoConcurrencyPerCPU = 16
+
+// autoConcurrency picks the concurrency for n queries when MaxConcurrency is not set:
+// enough to keep every CPU busy between network waits, but never more than there is
+// work or maxAutoConcurrency
+func autoConcurrency(n int) int {
+	concurrency := runtime.NumCPU() * autoConcurrencyPerCPU
+	if concurrency > maxAutoConcurrency {
+		concurrency = maxAutoConcurrency
+	}
+	if n < concurrency {
+		concurrency = n
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return concurrency
+}
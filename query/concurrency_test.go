@@ -0,0 +1,75 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoConcurrency(t *testing.T) {
+	perCPU := runtime.NumCPU() * autoConcurrencyPerCPU
+	if perCPU > maxAutoConcurrency {
+		perCPU = maxAutoConcurrency
+	}
+
+	assert.Equal(t, 1, autoConcurrency(0))
+	assert.Equal(t, 1, autoConcurrency(1))
+	assert.Equal(t, perCPU, autoConcurrency(100000))
+	assert.LessOrEqual(t, autoConcurrency(100000), maxAutoConcurrency)
+}
+
+func TestDiscoverServers_AutoConcurrencyIsBounded(t *testing.T) {
+	var inFlight, peak int32
+	original := scanPort
+	scanPort = func(ctx context.Context, host string, port int, options *QueryOptions) (*protocol.ServerInfo, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			previous := atomic.LoadInt32(&peak)
+			if current <= previous || atomic.CompareAndSwapInt32(&peak, previous, current) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil, fmt.Errorf("no server")
+	}
+	defer func() { scanPort = original }()
+
+	// WithMaxConcurrency(0) is auto, the same as leaving it unset
+	_, err := DiscoverServers(context.Background(), "127.0.0.1", WithPortRange(1, 5000), WithMaxConcurrency(0))
+
+	assert.NoError(t, err)
+	assert.Greater(t, peak, int32(1))
+	assert.LessOrEqual(t, int(peak), autoConcurrency(5000))
+}
+
+func TestDiscoverServers_LargeRangeBoundsGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	var peak int64
+	original := scanPort
+	scanPort = func(ctx context.Context, host string, port int, options *QueryOptions) (*protocol.ServerInfo, error) {
+		if port%100 == 0 {
+			current := int64(runtime.NumGoroutine())
+			for {
+				previous := atomic.LoadInt64(&peak)
+				if current <= previous || atomic.CompareAndSwapInt64(&peak, previous, current) {
+					break
+				}
+			}
+		}
+		return nil, fmt.Errorf("no server")
+	}
+	defer func() { scanPort = original }()
+
+	_, err := DiscoverServers(context.Background(), "127.0.0.1", WithPortRange(1, 60000), WithMaxConcurrency(8))
+
+	assert.NoError(t, err)
+	// The workers plus the port feeder and result closer, with some slack for the runtime
+	assert.LessOrEqual(t, int(atomic.LoadInt64(&peak)), baseline+8+10)
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// dnsCache resolves each hostname once per TTL and shares the result between
+// concurrent queries, so scans across many ports don't repeat the same lookup
+type dnsCache struct {
+	ttl     time.Duration
+	lookup  func(ctx context.Context, host string) ([]string, error)
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+// dnsEntry is a cached or in-flight lookup; ready is closed once addrs and err are set
+type dnsEntry struct {
+	ready   chan struct{}
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// dnsLookupTimeout bounds a shared lookup, which runs detached from the context of the
+// caller that started it
+const dnsLookupTimeout = 10 * time.Second
+
+func newDNSCache(ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		ttl:     ttl,
+		lookup:  net.DefaultResolver.LookupHost,
+		entries: make(map[string]*dnsEntry),
+	}
+}
+
+// LookupHost returns the cached addresses for host, resolving it if no fresh entry exists.
+// Failed lookups are not cached. The lookup is shared with concurrent callers, so it doesn't
+// stop when the caller that started it gives up; each caller only stops waiting on its
+// own context.
+func (c *dnsCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	c.mu.Lock()
+	entry, exists := c.entries[host]
+	if exists && entry.resolved() && time.Now().After(entry.expires) {
+		exists = false
+	}
+	if !exists {
+		entry = &dnsEntry{ready: make(chan struct{})}
+		c.entries[host] = entry
+		go c.resolve(context.WithoutCancel(ctx), host, entry)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.addrs, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolve runs the lookup for entry under its own timeout and publishes the result
+func (c *dnsCache) resolve(ctx context.Context, host string, entry *dnsEntry) {
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+	addrs, err := c.lookup(ctx, host)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.addrs, entry.err = addrs, err
+	entry.expires = time.Now().Add(c.ttl)
+	if err != nil && c.entries[host] == entry {
+		delete(c.entries, host)
+	}
+	close(entry.ready)
+}
+
+// resolved reports whether the lookup has finished
+func (e *dnsEntry) resolved() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSCache_ResolvesOncePerTTL(t *testing.T) {
+	var lookups int32
+	cache := newDNSCache(50 * time.Millisecond)
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		time.Sleep(10 * time.Millisecond)
+		return []string{"192.0.2.10"}, nil
+	}
+
+	// Concurrent lookups for the same host share one resolution
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addrs, err := cache.LookupHost(context.Background(), "game.example.com")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"192.0.2.10"}, addrs)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+
+	// IP literals never hit the resolver
+	addrs, err := cache.LookupHost(context.Background(), "127.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1"}, addrs)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+
+	// Expired entries are resolved again
+	time.Sleep(60 * time.Millisecond)
+	_, err = cache.LookupHost(context.Background(), "game.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+}
+
+func TestDNSCache_CancelledCallerDoesNotFailWaiters(t *testing.T) {
+	release := make(chan struct{})
+	cache := newDNSCache(time.Minute)
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		select {
+		case <-release:
+			return []string{"192.0.2.10"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// The first caller starts the lookup and then gives up on it
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := cache.LookupHost(firstCtx, "game.example.com")
+		firstDone <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	waiterDone := make(chan []string, 1)
+	go func() {
+		addrs, err := cache.LookupHost(context.Background(), "game.example.com")
+		assert.NoError(t, err)
+		waiterDone <- addrs
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstDone, context.Canceled)
+
+	close(release)
+	assert.Equal(t, []string{"192.0.2.10"}, <-waiterDone)
+}
+
+func TestDiscoverServers_ResolvesHostnameOnce(t *testing.T) {
+	var lookups atomic.Int32
+	savedLookup := discoveryLookup
+	discoveryLookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups.Add(1)
+		return []string{"127.0.0.1"}, nil
+	}
+	defer func() { discoveryLookup = savedLookup }()
+
+	ports := []int{
+		startA2SResponder(t, "Resolved Server 1", 440),
+		startA2SResponder(t, "Resolved Server 2", 440),
+		startA2SResponder(t, "Resolved Server 3", 440),
+	}
+	servers, err := DiscoverServers(context.Background(), "game.example.com", WithPorts(ports))
+
+	assert.NoError(t, err)
+	assert.Len(t, servers, 3)
+	assert.Equal(t, int32(1), lookups.Load())
+	for _, info := range servers {
+		assert.Equal(t, "game.example.com", info.Address)
+	}
+}
@@ -0,0 +1,147 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// defaultPlayerHistorySize is how many player count samples the engine keeps per server
+// unless WithPlayerHistory says otherwise
+const defaultPlayerHistorySize = 60
+
+// Engine runs queries with a shared set of default options and keeps per-server
+// state across calls, for long-running monitors that poll the same servers
+type Engine struct {
+	opts        []Option
+	historySize int
+	now         func() time.Time
+	mu          sync.Mutex
+	firstSeen   map[string]time.Time
+	history     map[string]*playerHistory
+}
+
+// NewEngine creates an engine whose queries apply opts before any per-call options
+func NewEngine(opts ...Option) *Engine {
+	options := &QueryOptions{PlayerHistory: defaultPlayerHistorySize}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &Engine{
+		opts:        opts,
+		historySize: options.PlayerHistory,
+		now:         time.Now,
+		firstSeen:   make(map[string]time.Time),
+		history:     make(map[string]*playerHistory),
+	}
+}
+
+// Query queries addr like Query and records whether the server was online.
+// Per-call opts are applied after the engine's defaults.
+func (e *Engine) Query(ctx context.Context, addr string, opts ...Option) (*protocol.ServerInfo, error) {
+	allOpts := make([]Option, 0, len(e.opts)+len(opts))
+	allOpts = append(allOpts, e.opts...)
+	allOpts = append(allOpts, opts...)
+
+	info, err := Query(ctx, addr, allOpts...)
+
+	// A query abandoned by the caller says nothing about the server
+	if err != nil && ctx.Err() != nil {
+		return info, err
+	}
+	online := err == nil && info != nil && info.Online
+	e.observe(addr, online)
+
+	players := 0
+	if online {
+		players = info.Players.Current
+	}
+	e.recordPlayers(addr, players)
+	return info, err
+}
+
+// Uptime returns how long addr has been continuously online across this engine's queries,
+// or 0 if its last query failed or it has never been queried. It is best-effort: it is
+// measured from the first successful query, so it never exceeds the time monitoring has
+// run, and outages shorter than the polling interval go unnoticed.
+func (e *Engine) Uptime(addr string) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	firstSeen, exists := e.firstSeen[addr]
+	if !exists {
+		return 0
+	}
+	return e.now().Sub(firstSeen)
+}
+
+// PlayerHistory returns the player counts from the most recent queries of addr, oldest
+// first, with 0 for polls where the server was offline. At most WithPlayerHistory samples
+// are kept per address; the engine keeps them for every address it has queried.
+func (e *Engine) PlayerHistory(addr string) []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	history, exists := e.history[addr]
+	if !exists {
+		return nil
+	}
+	return history.samples()
+}
+
+// recordPlayers appends a player count sample to the history of addr
+func (e *Engine) recordPlayers(addr string, players int) {
+	if e.historySize <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	history, exists := e.history[addr]
+	if !exists {
+		history = &playerHistory{buffer: make([]int, 0, e.historySize)}
+		e.history[addr] = history
+	}
+	history.add(players)
+}
+
+// playerHistory is a fixed-size ring buffer of player counts
+type playerHistory struct {
+	buffer []int
+	next   int
+}
+
+// add stores a sample, overwriting the oldest once the buffer is full
+func (h *playerHistory) add(players int) {
+	if len(h.buffer) < cap(h.buffer) {
+		h.buffer = append(h.buffer, players)
+		return
+	}
+	h.buffer[h.next] = players
+	h.next = (h.next + 1) % len(h.buffer)
+}
+
+// samples returns a copy of the stored samples, oldest first
+func (h *playerHistory) samples() []int {
+	samples := make([]int, 0, len(h.buffer))
+	samples = append(samples, h.buffer[h.next:]...)
+	return append(samples, h.buffer[:h.next]...)
+}
+
+// observe updates the uptime tracking of addr, resetting it when the server went offline
+func (e *Engine) observe(addr string, online bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !online {
+		delete(e.firstSeen, addr)
+		return
+	}
+	if _, exists := e.firstSeen[addr]; !exists {
+		e.firstSeen[addr] = e.now()
+	}
+}
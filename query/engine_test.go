@@ -0,0 +1,82 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_Uptime(t *testing.T) {
+	port := startA2SResponder(t, "Monitored Server", 440)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine := NewEngine(WithGame("a2s"), WithTimeout(time.Second))
+	engine.now = func() time.Time { return clock }
+
+	// Never queried
+	assert.Zero(t, engine.Uptime(addr))
+
+	// Uptime counts from the first successful query
+	_, err := engine.Query(context.Background(), addr)
+	assert.NoError(t, err)
+	clock = clock.Add(10 * time.Minute)
+	_, err = engine.Query(context.Background(), addr)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, engine.Uptime(addr))
+
+	// A query the caller cancelled leaves it alone
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = engine.Query(ctx, addr)
+	assert.Error(t, err)
+	assert.Equal(t, 10*time.Minute, engine.Uptime(addr))
+
+	// Going offline resets it
+	engine.observe(addr, false)
+	assert.Zero(t, engine.Uptime(addr))
+
+	// And it restarts from the next successful query
+	_, err = engine.Query(context.Background(), addr)
+	assert.NoError(t, err)
+	clock = clock.Add(time.Minute)
+	assert.Equal(t, time.Minute, engine.Uptime(addr))
+}
+
+func TestPlayerHistory_Ring(t *testing.T) {
+	history := &playerHistory{buffer: make([]int, 0, 3)}
+	history.add(1)
+	history.add(2)
+	assert.Equal(t, []int{1, 2}, history.samples())
+
+	history.add(3)
+	history.add(4)
+	history.add(5)
+	assert.Equal(t, []int{3, 4, 5}, history.samples())
+}
+
+func TestEngine_PlayerHistory(t *testing.T) {
+	port := startA2SResponder(t, "Monitored Server", 440)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	engine := NewEngine(WithGame("a2s"), WithTimeout(time.Second), WithPlayerHistory(2))
+	assert.Nil(t, engine.PlayerHistory(addr))
+
+	for i := 0; i < 3; i++ {
+		_, err := engine.Query(context.Background(), addr)
+		assert.NoError(t, err)
+	}
+
+	// The responder always reports one player; only the last two samples are kept
+	assert.Equal(t, []int{1, 1}, engine.PlayerHistory(addr))
+
+	// Disabled history records nothing
+	disabled := NewEngine(WithGame("a2s"), WithTimeout(time.Second), WithPlayerHistory(0))
+	_, err := disabled.Query(context.Background(), addr)
+	assert.NoError(t, err)
+	assert.Nil(t, disabled.PlayerHistory(addr))
+}
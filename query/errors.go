@@ -0,0 +1,88 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// ErrorClass is the broad reason a query failed, for telling a broken DNS record from a
+// server that is down or firewalled
+type ErrorClass int
+
+// Error classes, ordered from least to most telling about the cause; mostTellingError
+// relies on the order
+const (
+	// ClassUnknown covers nil errors, cancellation and anything not recognized below
+	ClassUnknown ErrorClass = iota
+	// ClassTimeout means nothing answered in time: the server is down or a firewall drops the packets
+	ClassTimeout
+	// ClassConnectionRefused means the host is up but nothing listens on the port
+	ClassConnectionRefused
+	// ClassProtocolError means something answered but not in a protocol that was tried
+	ClassProtocolError
+	// ClassNetworkUnreachable means there is no route to the host
+	ClassNetworkUnreachable
+	// ClassDNSFailure means the hostname could not be resolved
+	ClassDNSFailure
+)
+
+// String returns the class name in snake case, e.g. "connection_refused"
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassTimeout:
+		return "timeout"
+	case ClassConnectionRefused:
+		return "connection_refused"
+	case ClassProtocolError:
+		return "protocol_error"
+	case ClassNetworkUnreachable:
+		return "network_unreachable"
+	case ClassDNSFailure:
+		return "dns_failure"
+	}
+	return "unknown"
+}
+
+// ClassifyError reports why a query failed by inspecting the network errors wrapped in err.
+// Errors with no network cause, such as a malformed response or an HTTP error status, come
+// from a server that answered and are protocol errors.
+func ClassifyError(err error) ErrorClass {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ClassUnknown
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ClassDNSFailure
+	}
+	if protocol.IsConnectionRefused(err) {
+		return ClassConnectionRefused
+	}
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) {
+		return ClassNetworkUnreachable
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ClassTimeout
+	}
+
+	// Other socket and address failures say nothing about the server
+	var opErr *net.OpError
+	var addrErr *net.AddrError
+	if errors.As(err, &opErr) || errors.As(err, &addrErr) {
+		return ClassUnknown
+	}
+	return ClassProtocolError
+}
+
+// mostTellingError returns whichever of current and err says most about the cause
+func mostTellingError(current, err error) error {
+	if current == nil || ClassifyError(err) > ClassifyError(current) {
+		return err
+	}
+	return current
+}
@@ -0,0 +1,59 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	dial := func(err error) error {
+		return fmt.Errorf("connection failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", err)})
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorClass
+	}{
+		{"nil", nil, ClassUnknown},
+		{"cancelled", fmt.Errorf("read failed: %w", context.Canceled), ClassUnknown},
+		{"dns", dial(&net.DNSError{Err: "no such host", Name: "nowhere.invalid", IsNotFound: true}), ClassDNSFailure},
+		{"refused", dial(syscall.ECONNREFUSED), ClassConnectionRefused},
+		{"network unreachable", dial(syscall.ENETUNREACH), ClassNetworkUnreachable},
+		{"host unreachable", dial(syscall.EHOSTUNREACH), ClassNetworkUnreachable},
+		{"read timeout", fmt.Errorf("read failed: %w", &net.OpError{Op: "read", Net: "udp", Err: os.ErrDeadlineExceeded}), ClassTimeout},
+		{"context deadline", fmt.Errorf("query failed: %w", context.DeadlineExceeded), ClassTimeout},
+		{"other socket failure", dial(syscall.EMFILE), ClassUnknown},
+		{"bad response", fmt.Errorf("parse failed: invalid response header"), ClassProtocolError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestQuery_ErrorClassified(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	// Minecraft's TCP connection is refused by the closed port
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(closedPort(t)))
+	_, err := Query(context.Background(), addr, WithGame("minecraft"), WithTimeout(200*time.Millisecond))
+	assert.Equal(t, ClassConnectionRefused, ClassifyError(err))
+
+	results := QueryBatch(context.Background(), []string{addr}, WithGame("minecraft"), WithTimeout(200*time.Millisecond))
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "connection_refused", results[0].Extra["error_class"])
+	}
+}
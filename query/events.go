@@ -0,0 +1,42 @@
+package query
+
+import "time"
+
+// ScanResult is the outcome of one protocol attempt on a port
+type ScanResult string
+
+const (
+	// ScanFound means the protocol answered and a server was found
+	ScanFound ScanResult = "found"
+	// ScanDead means nothing answered: the attempt timed out or the port was refused,
+	// including protocols skipped because their transport was already refused
+	ScanDead ScanResult = "dead"
+	// ScanError means something answered but the protocol failed against it
+	ScanError ScanResult = "error"
+)
+
+// ScanEvent describes one protocol attempt on a port during discovery or auto-detection
+type ScanEvent struct {
+	Port     int
+	Protocol string
+	Result   ScanResult
+	Latency  time.Duration // Time the attempt took, 0 for skipped protocols
+	Err      error         // Why the attempt failed, nil when found
+}
+
+// emitScanEvent reports an attempt to the WithScanEventCallback callback, if any
+func emitScanEvent(options *QueryOptions, port int, protocolName string, latency time.Duration, err error) {
+	if options.ScanEvents == nil {
+		return
+	}
+	event := ScanEvent{Port: port, Protocol: protocolName, Result: ScanFound, Latency: latency, Err: err}
+	if err != nil {
+		switch ClassifyError(err) {
+		case ClassTimeout, ClassConnectionRefused, ClassNetworkUnreachable:
+			event.Result = ScanDead
+		default:
+			event.Result = ScanError
+		}
+	}
+	options.ScanEvents(event)
+}
@@ -0,0 +1,49 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverServers_ScanEvents(t *testing.T) {
+	serverPort := startA2SResponder(t, "Event Server", 440)
+	deadPort := closedPort(t)
+
+	var mu sync.Mutex
+	var events []ScanEvent
+	record := func(event ScanEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	servers, err := DiscoverServers(context.Background(), "127.0.0.1",
+		WithPorts([]int{serverPort, deadPort}), WithTimeout(500*time.Millisecond), WithScanEventCallback(record))
+	assert.NoError(t, err)
+	assert.Len(t, servers, 1)
+
+	found := 0
+	deadAttempts := 0
+	for _, event := range events {
+		switch event.Port {
+		case serverPort:
+			if event.Result == ScanFound {
+				found++
+				assert.Equal(t, "a2s", event.Protocol)
+				assert.Greater(t, event.Latency, time.Duration(0))
+				assert.NoError(t, event.Err)
+			}
+		case deadPort:
+			// Every protocol on the closed port is refused or skipped
+			deadAttempts++
+			assert.Equal(t, ScanDead, event.Result, event.Protocol)
+			assert.Error(t, event.Err)
+		}
+	}
+	assert.Equal(t, 1, found)
+	assert.Equal(t, len(portProtocols()), deadAttempts)
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"strconv"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// applyUnknownGameName asks fn to name a server whose game was not detected, which shows
+// as the generic protocol name. Only servers reporting an App ID are passed to fn.
+func applyUnknownGameName(info *protocol.ServerInfo, protocolName string, fn func(appID int, desc string) string) {
+	if info.Game != protocolName || info.Extra == nil {
+		return
+	}
+	appID, err := strconv.Atoi(info.Extra["app_id"])
+	if err != nil {
+		return
+	}
+	if game := fn(appID, info.Extra["game"]); game != "" {
+		info.Game = game
+	}
+}
+
+// flagUnexpectedAppID marks servers reporting an App ID outside expected. Servers that
+// report no App ID are left alone, since their protocol has no way to say.
+func flagUnexpectedAppID(info *protocol.ServerInfo, expected map[int]bool) {
+	appID, err := strconv.Atoi(info.Extra["app_id"])
+	if err != nil || expected[appID] {
+		return
+	}
+	info.Extra["unexpected_app_id"] = "true"
+}
+
+// preferProtocol moves the named protocol to the front, keeping the others in order.
+func preferProtocol(protocols []protocol.Protocol, name string) []protocol.Protocol {
+	ordered := make([]protocol.Protocol, 0, len(protocols))
+	for _, proto := range protocols {
+		if proto.Name() == name {
+			ordered = append(ordered, proto)
+		}
+	}
+	for _, proto := range protocols {
+		if proto.Name() != name {
+			ordered = append(ordered, proto)
+		}
+	}
+	return ordered
+}
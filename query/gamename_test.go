@@ -0,0 +1,63 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery_UnknownGameName(t *testing.T) {
+	var calls []int
+	names := func(appID int, desc string) string {
+		calls = append(calls, appID)
+		if appID == 12345 && desc == "Counter-Strike" {
+			return "my-game"
+		}
+		return ""
+	}
+
+	// Unknown App IDs are passed to the hook
+	unknown := net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Unknown Server", 12345)))
+	info, err := Query(context.Background(), unknown, WithGame("a2s"), WithUnknownGameName(names))
+	assert.NoError(t, err)
+	assert.Equal(t, "my-game", info.Game)
+
+	// An empty answer keeps the generic name
+	other := net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Other Server", 23456)))
+	info, err = Query(context.Background(), other, WithGame("a2s"), WithUnknownGameName(names))
+	assert.NoError(t, err)
+	assert.Equal(t, "a2s", info.Game)
+
+	// Known games never reach the hook
+	known := net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Known Server", 440)))
+	info, err = Query(context.Background(), known, WithGame("a2s"), WithUnknownGameName(names))
+	assert.NoError(t, err)
+	assert.Equal(t, "team-fortress-2", info.Game)
+
+	assert.Equal(t, []int{12345, 23456}, calls)
+}
+
+func TestQuery_ExpectedAppIDs(t *testing.T) {
+	expected := WithExpectedAppIDs([]int{440, 4000})
+
+	known := net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Catalog Server", 440)))
+	info, err := Query(context.Background(), known, WithGame("a2s"), expected)
+	assert.NoError(t, err)
+	assert.NotContains(t, info.Extra, "unexpected_app_id")
+
+	other := net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Stray Server", 550)))
+	info, err = Query(context.Background(), other, WithGame("a2s"), expected)
+	assert.NoError(t, err)
+	assert.Equal(t, "left-4-dead-2", info.Game)
+	assert.Equal(t, "true", info.Extra["unexpected_app_id"])
+}
+
+func TestPreferProtocol(t *testing.T) {
+	protocols := preferProtocol(portProtocols(), "a2s")
+	assert.Equal(t, "a2s", protocols[0].Name())
+	assert.Equal(t, "minecraft", protocols[1].Name())
+	assert.Len(t, protocols, len(portProtocols()))
+}
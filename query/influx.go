@@ -0,0 +1,53 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// InfluxMeasurement is the measurement name of the points written by FormatInfluxLine
+const InfluxMeasurement = "gameserver"
+
+// influxTagEscaper escapes the characters line protocol gives meaning to in tag values
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// FormatInfluxLine writes one InfluxDB line protocol point per server to w, e.g.
+//
+//	gameserver,game=minecraft,address=10.0.0.1:25565 players=4i,max=20i,ping=12i,online=true 1714564800000000000
+//
+// The address tag is the address and port that answered. Offline servers are written with
+// online=false so dashboards see them drop out. Points are stamped with QueriedAt, or left
+// for the database to stamp when it is unset.
+func FormatInfluxLine(w io.Writer, servers []*protocol.ServerInfo) error {
+	for _, info := range servers {
+		if info == nil {
+			continue
+		}
+		if _, err := io.WriteString(w, influxLine(info)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// influxLine formats a single point, newline included
+func influxLine(info *protocol.ServerInfo) string {
+	var line strings.Builder
+	line.WriteString(InfluxMeasurement)
+	// Empty tag values are invalid in line protocol, so an undetected game is left out
+	if info.Game != "" {
+		line.WriteString(",game=" + influxTagEscaper.Replace(info.Game))
+	}
+	line.WriteString(",address=" + influxTagEscaper.Replace(snapshotKey(info)))
+
+	fmt.Fprintf(&line, " players=%di,max=%di,ping=%di,online=%t",
+		info.Players.Current, info.Players.Max, info.Ping, info.Online)
+	if !info.QueriedAt.IsZero() {
+		fmt.Fprintf(&line, " %d", info.QueriedAt.UnixNano())
+	}
+	line.WriteString("\n")
+	return line.String()
+}
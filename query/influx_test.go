@@ -0,0 +1,28 @@
+package query
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatInfluxLine(t *testing.T) {
+	online := snapshotServer("10.0.0.1", 25565, 4)
+	online.Ping = 12
+	online.QueriedAt = time.Unix(1714564800, 0)
+	offline := &protocol.ServerInfo{Address: "2001:db8::1", Port: 27015}
+	escaped := snapshotServer("10.0.0.2", 27015, 0)
+	escaped.Game = "my game,beta"
+
+	var buf bytes.Buffer
+	assert.NoError(t, FormatInfluxLine(&buf, []*protocol.ServerInfo{online, nil, offline, escaped}))
+
+	assert.Equal(t,
+		"gameserver,game=minecraft,address=10.0.0.1:25565 players=4i,max=20i,ping=12i,online=true 1714564800000000000\n"+
+			"gameserver,address=[2001:db8::1]:27015 players=0i,max=0i,ping=0i,online=false\n"+
+			`gameserver,game=my\ game\,beta,address=10.0.0.2:27015 players=0i,max=20i,ping=0i,online=true`+"\n",
+		buf.String())
+}
@@ -0,0 +1,38 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// unknownListenerGame is the Game of discovery entries for listeners no protocol recognized
+const unknownListenerGame = "unknown"
+
+// hasTCPListener reports whether host accepts TCP connections on port. It only dials and
+// never writes, so query options such as WithPlayers add no traffic to the check.
+func hasTCPListener(ctx context.Context, host string, port int, timeout time.Duration) bool {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// unknownListenerInfo describes a port with a TCP listener that matched no protocol
+func unknownListenerInfo(host string, port int) *protocol.ServerInfo {
+	return &protocol.ServerInfo{
+		Game:      unknownListenerGame,
+		Address:   host,
+		Port:      port,
+		QueryPort: port,
+		Online:    false,
+		QueriedAt: time.Now(),
+		Extra:     map[string]string{"listener": "tcp"},
+	}
+}
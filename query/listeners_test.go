@@ -0,0 +1,79 @@
+package query
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverServers_ReportUnknownListeners(t *testing.T) {
+	// A TCP service that speaks none of the supported protocols
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return // Listener closed
+			}
+			conn.Close()
+		}
+	}()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	// Not reported by default
+	servers, err := DiscoverServers(context.Background(), "127.0.0.1", WithPorts([]int{port}), WithTimeout(200*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Empty(t, servers)
+
+	// Reported as an offline unknown entry when requested
+	servers, err = DiscoverServers(context.Background(), "127.0.0.1", WithPorts([]int{port}), WithTimeout(200*time.Millisecond), WithReportUnknownListeners())
+	assert.NoError(t, err)
+	if assert.Len(t, servers, 1) {
+		assert.Equal(t, "unknown", servers[0].Game)
+		assert.Equal(t, port, servers[0].Port)
+		assert.False(t, servers[0].Online)
+		assert.Equal(t, "tcp", servers[0].Extra["listener"])
+	}
+
+	// Closed ports are never reported
+	servers, err = DiscoverServers(context.Background(), "127.0.0.1", WithPorts([]int{closedPort(t)}), WithTimeout(200*time.Millisecond), WithReportUnknownListeners())
+	assert.NoError(t, err)
+	assert.Empty(t, servers)
+}
+
+func TestHasTCPListener_SendsNothing(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	defer l.Close()
+	received := make(chan int, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return // Listener closed
+		}
+		defer conn.Close()
+		total := 0
+		buffer := make([]byte, 1500)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			n, err := conn.Read(buffer)
+			total += n
+			if err != nil {
+				break
+			}
+		}
+		received <- total
+	}()
+
+	assert.True(t, hasTCPListener(context.Background(), "127.0.0.1", l.Addr().(*net.TCPAddr).Port, time.Second))
+	assert.Equal(t, 0, <-received)
+}
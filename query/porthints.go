@@ -0,0 +1,34 @@
+package query
+
+import "github.com/0xkowalskidev/gameserverquery/protocol"
+
+// expectedProtocols returns the protocols conventionally found on port: those whose
+// default ports, or whose games' game or query ports, include it
+func expectedProtocols(port int) map[string]bool {
+	expected := make(map[string]bool)
+	for _, info := range protocol.ListProtocols() {
+		if info.DefaultPort == port || info.DefaultQueryPort == port {
+			expected[info.Name] = true
+		}
+		for _, game := range info.Games {
+			if game.GamePort == port || game.QueryPort == port {
+				expected[info.Name] = true
+			}
+		}
+	}
+	return expected
+}
+
+// flagUnexpectedProtocol sets info.Extra["unexpected_protocol_for_port"] when a well-known
+// port answered a protocol other than the ones it conventionally implies, which usually
+// points at a misconfigured or non-standard setup. Ports no protocol claims are not flagged.
+func flagUnexpectedProtocol(info *protocol.ServerInfo, protocolName string, port int) {
+	expected := expectedProtocols(port)
+	if len(expected) == 0 || expected[protocolName] {
+		return
+	}
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["unexpected_protocol_for_port"] = "true"
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagUnexpectedProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		port     int
+		flagged  bool
+	}{
+		{"minecraft on its port", "minecraft", 25565, false},
+		{"minecraft query on the minecraft port", "minecraft-query", 25565, false},
+		{"source on the minecraft port", "a2s", 25565, true},
+		{"minecraft on the source port", "minecraft", 27015, true},
+		{"source on a game's separate query port", "a2s", 27165, false},
+		{"port no protocol claims", "minecraft", 40000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &protocol.ServerInfo{}
+			flagUnexpectedProtocol(info, tt.protocol, tt.port)
+			if tt.flagged {
+				assert.Equal(t, "true", info.Extra["unexpected_protocol_for_port"])
+			} else {
+				assert.NotContains(t, info.Extra, "unexpected_protocol_for_port")
+			}
+		})
+	}
+}
+
+func TestTryPort_NoHintOnUnclaimedPort(t *testing.T) {
+	port := startA2SResponder(t, "Random Port Server", 440)
+
+	info, err := tryPort(context.Background(), "127.0.0.1", port, &QueryOptions{Timeout: time.Second})
+
+	assert.NoError(t, err)
+	assert.NotContains(t, info.Extra, "unexpected_protocol_for_port")
+}
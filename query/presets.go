@@ -0,0 +1,64 @@
+package query
+
+import (
+	"sort"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// portPresets maps preset names to functions building their port lists
+var portPresets = map[string]func() []int{
+	"steam":     func() []int { return portRange(27015, 27020) },
+	"minecraft": func() []int { return portRange(25565, 25575) },
+	"survival": func() []int {
+		return gamePorts("ark-survival-evolved", "rust", "valheim", "7-days-to-die")
+	},
+}
+
+// PortPreset returns the ports scanned by a named preset
+func PortPreset(name string) ([]int, bool) {
+	build, exists := portPresets[name]
+	if !exists {
+		return nil, false
+	}
+	return build(), true
+}
+
+// PortPresetNames returns the names of all port presets in alphabetical order
+func PortPresetNames() []string {
+	names := make([]string, 0, len(portPresets))
+	for name := range portPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// portRange returns all ports from start to end inclusive
+func portRange(start, end int) []int {
+	ports := make([]int, 0, end-start+1)
+	for port := start; port <= end; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// gamePorts returns the sorted union of the game and query ports of the given games
+func gamePorts(games ...string) []int {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, game := range games {
+		config, _, exists := protocol.GetGameConfigFromRegistry(game)
+		if !exists {
+			continue
+		}
+		for _, port := range []int{config.GamePort, config.QueryPort} {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
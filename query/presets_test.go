@@ -0,0 +1,35 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortPreset(t *testing.T) {
+	steam, exists := PortPreset("steam")
+	assert.True(t, exists)
+	assert.Equal(t, []int{27015, 27016, 27017, 27018, 27019, 27020}, steam)
+
+	// Survival ports come from the registry's game configs
+	survival, exists := PortPreset("survival")
+	assert.True(t, exists)
+	for _, port := range []int{7777, 27015, 28015, 2456, 2457, 26900} {
+		assert.Contains(t, survival, port)
+	}
+
+	_, exists = PortPreset("unknown")
+	assert.False(t, exists)
+}
+
+func TestWithPortPreset(t *testing.T) {
+	options := &QueryOptions{}
+	WithPortPreset("minecraft")(options)
+	assert.Len(t, options.PortRange, 11)
+	assert.Equal(t, 25565, options.PortRange[0])
+
+	// Unknown presets leave the scan configuration untouched
+	options = &QueryOptions{PortRange: []int{1234}}
+	WithPortPreset("unknown")(options)
+	assert.Equal(t, []int{1234}, options.PortRange)
+}
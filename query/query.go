@@ -2,12 +2,19 @@ package query
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"math"
 	"net"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/0xkowalskidev/gameserverquery/protocol"
 )
@@ -17,13 +24,51 @@ type Option func(*QueryOptions)
 
 // QueryOptions holds all query configuration
 type QueryOptions struct {
-	Game           string
-	Port           int
-	Timeout        time.Duration
-	Players        bool
-	PortRange      []int
-	MaxConcurrency int
-	Debug          bool
+	Game            string
+	Port            int
+	Timeout         time.Duration
+	Players         bool
+	PortRange       []int
+	MaxConcurrency  int
+	Debug           bool
+	Logger          *slog.Logger
+	Capabilities    bool
+	MaxResults      int
+	Retries         int
+	SanityChecks    bool
+	MaxNameLength   int
+	ParallelQueries bool
+	OnlineOnly      bool
+	PingSamples     int
+	ForceGame       string
+	DNSCache        *dnsCache
+	HTTPTimeout     time.Duration
+	MaxResponseSize int
+	StripColorCodes bool
+	Timings         bool
+	Rules           bool
+	StateHash       bool
+	ReportListeners bool
+	DedupPlayers    bool
+	UnknownGameName func(appID int, desc string) string
+	Scoreboard      bool
+	ExcludeBots     bool
+	RegionFromName  bool
+	PlayerHistory   int
+	TLSConfig       *tls.Config
+	IncludeOffline  bool
+	PlayerLimit     int
+	ExpectedAppIDs  map[int]bool
+	RconPassword    string
+	RconPort        int
+	MaxRedirects    int
+	ScanEvents      func(ScanEvent)
+	TimingHistogram bool
+	// AdjacentPortOffsets are tried relative to the given port before the common ports
+	AdjacentPortOffsets []int
+	// queryID and queryTarget tag debug lines of one query, set by tagQuery
+	queryID     string
+	queryTarget string
 }
 
 // ScanProgress represents the progress of a server scan
@@ -35,12 +80,27 @@ type ScanProgress struct {
 }
 
 // Common game server ports - simplified hardcoded list
-var commonPorts = []int{25565, 27015, 7777, 28015, 27016, 7778, 25564}
+var commonPorts = []int{25565, 27015, 7777, 28015, 27016, 7778, 25564, 27165, 27131, 19132}
+
+// autoDetectAttemptTimeout bounds each protocol attempt during auto-detection, whether or
+// not a port was given; a query with a game keeps the full timeout
+const autoDetectAttemptTimeout = protocol.DiscoveryTimeout * 3
+
+// discoveryDNSTTL bounds how long a scan reuses its initial lookup of the hostname
+const discoveryDNSTTL = 5 * time.Minute
+
+// discoveryLookup resolves the scanned hostname; tests replace it to count lookups
+var discoveryLookup = net.DefaultResolver.LookupHost
 
 // Protocol order by popularity
 var protocolOrder = []string{"minecraft", "a2s", "terraria"}
 
-// Query queries a server with automatic game detection if no game specified
+// Query queries a server with automatic game detection if no game specified.
+// On success the returned ServerInfo is always online; when no server answers,
+// Query returns a nil ServerInfo and an error rather than an offline entry.
+// Use QueryBatch to get offline entries for addresses that fail.
+// Auto-detection caps each protocol attempt at autoDetectAttemptTimeout (900ms), so
+// give a game to wait the full timeout for a slow server.
 func Query(ctx context.Context, addr string, opts ...Option) (*protocol.ServerInfo, error) {
 	options := &QueryOptions{
 		Timeout: 5 * time.Second,
@@ -48,9 +108,10 @@ func Query(ctx context.Context, addr string, opts ...Option) (*protocol.ServerIn
 	for _, opt := range opts {
 		opt(options)
 	}
+	tagQuery(options, addr)
 
 	if options.Debug {
-		fmt.Printf("[DEBUG] Query: Starting query for '%s'\n", addr)
+		debugLogf(options, "Query", "Starting query for '%s'", addr)
 	}
 
 	// Parse address
@@ -59,45 +120,141 @@ func Query(ctx context.Context, addr string, opts ...Option) (*protocol.ServerIn
 		return nil, fmt.Errorf("invalid address: %w", err)
 	}
 
+	// The failure reported is the most telling one for the game or port asked for, falling
+	// back to the common ports only when neither was given
+	var primaryErr, fallbackErr error
+
 	// Try specific game first if provided
 	if options.Game != "" {
 		if options.Debug {
-			fmt.Printf("[DEBUG] Query: Trying specific game '%s'\n", options.Game)
+			debugLogf(options, "Query", "Trying specific game '%s'", options.Game)
 		}
-		if info, err := trySpecificGame(ctx, options.Game, host, port, options); err == nil {
+		info, err := trySpecificGame(ctx, options.Game, host, port, options)
+		if err == nil {
 			return info, nil
 		}
+		primaryErr = err
 		if options.Debug {
-			fmt.Printf("[DEBUG] Query: Specific game '%s' failed, trying auto-detect\n", options.Game)
+			debugLogf(options, "Query", "Specific game '%s' failed, trying auto-detect", options.Game)
 		}
 	}
 
 	// Auto-detect: try protocols in order of popularity
 	if options.Debug {
-		fmt.Printf("[DEBUG] Query: Auto-detecting game type\n")
+		debugLogf(options, "Query", "Auto-detecting game type")
 	}
 
-	// Try exact port first
+	// Bound each protocol attempt, on the given port and the common ones alike, so an
+	// offline host can't cost the full timeout for every port and protocol combination
+	autoOptions := *options
+	if autoOptions.Timeout <= 0 || autoOptions.Timeout > autoDetectAttemptTimeout {
+		autoOptions.Timeout = autoDetectAttemptTimeout
+	}
+
+	// Try exact port first, then its neighbours
+	tried := make(map[int]bool)
 	if port > 0 {
-		if info, err := tryPort(ctx, host, port, options); err == nil {
+		info, err := tryPort(ctx, host, port, &autoOptions)
+		if err == nil {
 			return info, nil
 		}
+		primaryErr = mostTellingError(primaryErr, err)
+		tried[port] = true
+
+		for _, testPort := range adjacentPorts(port, options.AdjacentPortOffsets) {
+			info, err := tryPort(ctx, host, testPort, &autoOptions)
+			if err == nil {
+				return info, nil
+			}
+			fallbackErr = mostTellingError(fallbackErr, err)
+			tried[testPort] = true
+		}
 	}
 
 	// Try common ports
 	for _, testPort := range commonPorts {
-		if testPort == port {
+		if tried[testPort] {
 			continue // Already tried
 		}
-		if info, err := tryPort(ctx, host, testPort, options); err == nil {
+		info, err := tryPort(ctx, host, testPort, &autoOptions)
+		if err == nil {
 			return info, nil
 		}
+		fallbackErr = mostTellingError(fallbackErr, err)
+	}
+
+	cause := primaryErr
+	if cause == nil {
+		cause = fallbackErr
+	}
+	if cause == nil {
+		return nil, relayHint(options.Game, fmt.Errorf("no responsive server found at %s", addr))
+	}
+	return nil, relayHint(options.Game, fmt.Errorf("no responsive server found at %s: %w", addr, cause))
+}
+
+// IsOnline reports whether a server answers its protocol's probe, skipping full
+// response parsing and player queries. Without a game it falls back to Query's auto-detection.
+func IsOnline(ctx context.Context, game, addr string, opts ...Option) (bool, error) {
+	if game == "" {
+		if _, err := Query(ctx, addr, opts...); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	options := &QueryOptions{
+		Timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	host, port, err := parseAddress(addr, options.Port)
+	if err != nil {
+		return false, fmt.Errorf("invalid address: %w", err)
+	}
+
+	gameConfig, proto, exists := protocol.GetGameConfigFromRegistry(game)
+	if !exists {
+		return false, fmt.Errorf("unsupported game: %s", game)
+	}
+	if port == 0 {
+		port = gameConfig.QueryPort
+	}
+
+	prober, ok := proto.(protocol.Prober)
+	if !ok {
+		// Protocols without a cheap probe fall back to an info-only query
+		options.Players = false
+		if _, err := queryProtocol(ctx, proto, host, port, options); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
 
-	return nil, fmt.Errorf("no responsive server found at %s", addr)
+	tagQuery(options, addr)
+	protoOpts := &protocol.Options{
+		Timeout:     options.Timeout,
+		Debug:       options.Debug,
+		Logger:      options.Logger,
+		QueryID:     options.queryID,
+		QueryTarget: options.queryTarget,
+	}
+	if options.DNSCache != nil {
+		protoOpts.LookupHost = options.DNSCache.LookupHost
+	}
+	if err := probeProtocol(ctx, prober, proto.Name(), net.JoinHostPort(host, strconv.Itoa(port)), protoOpts); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-// DiscoverServers scans for multiple game servers on the given host
+// DiscoverServers scans for multiple game servers on the given host. Only servers
+// that answered are returned, so results are always online and WithOnlineOnly has no effect.
+// The exceptions are WithReportUnknownListeners, which adds offline "unknown" entries, and
+// WithIncludeOffline, which adds an offline entry for each given port that didn't answer.
+// A hostname is resolved once for the whole scan, or through WithDNSCache when given.
 func DiscoverServers(ctx context.Context, addr string, opts ...Option) ([]*protocol.ServerInfo, error) {
 	return discoverServers(ctx, addr, opts, nil)
 }
@@ -132,7 +289,7 @@ func discoverServers(ctx context.Context, addr string, opts []Option, progressCa
 	}
 
 	if options.Debug {
-		fmt.Printf("[DEBUG] Discovery: Starting discovery for '%s'\n", addr)
+		debugLogf(options, "Discovery", "Starting discovery for '%s'", addr)
 	}
 
 	// Parse address
@@ -141,6 +298,17 @@ func discoverServers(ctx context.Context, addr string, opts []Option, progressCa
 		return nil, fmt.Errorf("invalid address: %w", err)
 	}
 
+	// Resolve a hostname once for the whole scan instead of on every probe's dial. Probes
+	// still address the hostname, which results and Minecraft's handshake carry. A failed
+	// lookup isn't cached, so each probe then resolves for itself.
+	if options.DNSCache == nil && net.ParseIP(host) == nil {
+		options.DNSCache = newDNSCache(discoveryDNSTTL)
+		options.DNSCache.lookup = discoveryLookup
+		if _, err := options.DNSCache.LookupHost(ctx, host); err != nil && options.Debug {
+			debugLogf(options, "Discovery", "Resolving '%s' failed, resolving per probe: %v", host, err)
+		}
+	}
+
 	// Determine ports to scan
 	var portsToScan []int
 	if len(options.PortRange) > 0 {
@@ -151,16 +319,22 @@ func discoverServers(ctx context.Context, addr string, opts []Option, progressCa
 		portsToScan = commonPorts
 	}
 
+	// Offline entries only make sense for ports the caller named, not the common port guesses
+	reportOffline := options.IncludeOffline && (len(options.PortRange) > 0 || specifiedPort > 0)
+
 	if options.Debug {
-		fmt.Printf("[DEBUG] Discovery: Scanning %d ports\n", len(portsToScan))
+		debugLogf(options, "Discovery", "Scanning %d ports", len(portsToScan))
 	}
 
+	// Cancel outstanding scans once enough servers are found
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Set up concurrency
 	maxConcurrency := options.MaxConcurrency
 	if maxConcurrency <= 0 {
-		maxConcurrency = 10 // Reasonable default
+		maxConcurrency = autoConcurrency(len(portsToScan))
 	}
-	semaphore := make(chan struct{}, maxConcurrency)
 
 	// Results collection
 	results := make(chan *protocol.ServerInfo, len(portsToScan))
@@ -178,47 +352,72 @@ func discoverServers(ctx context.Context, addr string, opts []Option, progressCa
 		})
 	}
 
-	// Scan each port
-	for _, port := range portsToScan {
-		wg.Add(1)
-		go func(port int) {
-			defer wg.Done()
+	// Scan each port on a fixed pool of workers, so a full port range costs
+	// maxConcurrency goroutines rather than one per port
+	scan := func(port int) {
+		// Workers share options, so each port gets a tagged copy for its debug lines
+		portOptions := options
+		if options.Debug {
+			tagged := *options
+			tagQuery(&tagged, net.JoinHostPort(host, strconv.Itoa(port)))
+			portOptions = &tagged
+		}
 
-			// Acquire semaphore
+		if info, err := scanPort(ctx, host, port, portOptions); err == nil {
+			results <- info
+		} else if options.ReportListeners && hasTCPListener(ctx, host, port, options.Timeout) {
+			if options.Debug {
+				debugLogf(portOptions, "Discovery", "Port %d has a TCP listener but no protocol matched", port)
+			}
+			results <- unknownListenerInfo(host, port)
+		} else if reportOffline && ctx.Err() == nil {
+			results <- offlineServerInfo(net.JoinHostPort(host, strconv.Itoa(port)), 0, err)
+		}
+
+		// Update progress
+		mu.Lock()
+		completed++
+		current := completed
+		mu.Unlock()
+
+		if progressCallback != nil {
+			// Count current servers
+			serversFound := 0
 			select {
-			case semaphore <- struct{}{}:
-			case <-ctx.Done():
-				return
+			case <-time.After(1 * time.Millisecond):
+				// Non-blocking check
+			default:
 			}
-			defer func() { <-semaphore }()
+			// Simple approximation for progress
+			progressCallback(ScanProgress{
+				TotalPorts:     len(portsToScan),
+				TotalProtocols: len(protocolOrder),
+				Completed:      current,
+				ServersFound:   serversFound,
+			})
+		}
+	}
 
-			if info, err := tryPort(ctx, host, port, options); err == nil {
-				results <- info
+	ports := make(chan int)
+	go func() {
+		defer close(ports)
+		for _, port := range portsToScan {
+			select {
+			case ports <- port:
+			case <-ctx.Done():
+				return
 			}
+		}
+	}()
 
-			// Update progress
-			mu.Lock()
-			completed++
-			current := completed
-			mu.Unlock()
-
-			if progressCallback != nil {
-				// Count current servers
-				serversFound := 0
-				select {
-				case <-time.After(1 * time.Millisecond):
-					// Non-blocking check
-				default:
-				}
-				// Simple approximation for progress
-				progressCallback(ScanProgress{
-					TotalPorts:     len(portsToScan),
-					TotalProtocols: len(protocolOrder),
-					Completed:      current,
-					ServersFound:   serversFound,
-				})
+	for i := 0; i < maxConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range ports {
+				scan(port)
 			}
-		}(port)
+		}()
 	}
 
 	// Wait for completion
@@ -227,14 +426,30 @@ func discoverServers(ctx context.Context, addr string, opts []Option, progressCa
 		close(results)
 	}()
 
-	// Collect results
+	// Collect results, draining until all scans have stopped
 	var servers []*protocol.ServerInfo
+	found := 0
 	for info := range results {
+		// Unknown listeners and offline ports are reported alongside servers but don't count towards MaxResults
+		if !info.Online {
+			servers = append(servers, info)
+			continue
+		}
+		if options.MaxResults > 0 && found >= options.MaxResults {
+			continue
+		}
 		servers = append(servers, info)
+		found++
+		if options.MaxResults > 0 && found >= options.MaxResults {
+			if options.Debug {
+				debugLogf(options, "Discovery", "Reached %d results, cancelling remaining scans", options.MaxResults)
+			}
+			cancel()
+		}
 	}
 
 	if options.Debug {
-		fmt.Printf("[DEBUG] Discovery: Found %d servers\n", len(servers))
+		debugLogf(options, "Discovery", "Found %d servers", len(servers))
 	}
 
 	return servers, nil
@@ -255,27 +470,74 @@ func trySpecificGame(ctx context.Context, game, host string, port int, options *
 	return queryProtocol(ctx, proto, host, port, options)
 }
 
+// scanPort queries one port during discovery; tests replace it to observe scheduling
+var scanPort = tryPort
+
 // tryPort tries all protocols on a specific port
 func tryPort(ctx context.Context, host string, port int, options *QueryOptions) (*protocol.ServerInfo, error) {
 	if options.Debug {
-		fmt.Printf("[DEBUG] Query: Trying port %d\n", port)
+		debugLogf(options, "Query", "Trying port %d", port)
+	}
+
+	// A refused connection or ICMP port unreachable means nothing listens on this port for
+	// that transport, so remaining protocols on the same transport are skipped
+	refused := make(map[string]bool)
+	var portErr error
+
+	protocols := portProtocols()
+	if len(options.ExpectedAppIDs) > 0 {
+		protocols = preferProtocol(protocols, "a2s")
 	}
 
-	// Try protocols in order of popularity
+	for _, proto := range protocols {
+		network := ""
+		if networkProto, ok := proto.(protocol.NetworkProtocol); ok {
+			network = networkProto.Network()
+		}
+		if network != "" && refused[network] {
+			if options.Debug {
+				debugLogf(options, "Query", "Skipping %s on port %d, %s refused", proto.Name(), port, network)
+			}
+			emitScanEvent(options, port, proto.Name(), 0, fmt.Errorf("skipped, %s refused: %w", network, syscall.ECONNREFUSED))
+			continue
+		}
+
+		attemptStart := time.Now()
+		info, err := queryProtocol(ctx, proto, host, port, options)
+		emitScanEvent(options, port, proto.Name(), time.Since(attemptStart), err)
+		if err == nil {
+			if options.Debug {
+				debugLogf(options, "Query", "SUCCESS with %s on port %d", proto.Name(), port)
+			}
+			flagUnexpectedProtocol(info, proto.Name(), port)
+			return info, nil
+		}
+		if network != "" && protocol.IsConnectionRefused(err) {
+			refused[network] = true
+		}
+		portErr = mostTellingError(portErr, err)
+	}
+
+	if portErr == nil {
+		return nil, fmt.Errorf("no protocol worked on port %d", port)
+	}
+	return nil, fmt.Errorf("no protocol worked on port %d: %w", port, portErr)
+}
+
+// portProtocols returns the protocols to try on a port: the most popular first, then the
+// rest by name. The order must be stable, since on a port where two protocols could both
+// answer the first to succeed decides the detected game.
+func portProtocols() []protocol.Protocol {
+	var protocols []protocol.Protocol
 	for _, protoName := range protocolOrder {
 		if proto, exists := protocol.GetProtocol(protoName); exists {
-			if info, err := queryProtocol(ctx, proto, host, port, options); err == nil {
-				if options.Debug {
-					fmt.Printf("[DEBUG] Query: SUCCESS with %s on port %d\n", proto.Name(), port)
-				}
-				return info, nil
-			}
+			protocols = append(protocols, proto)
 		}
 	}
 
-	// Try any remaining protocols
-	for _, proto := range protocol.AllProtocols() {
-		// Skip if already tried
+	// Add any remaining protocols
+	for _, proto := range protocol.AllProtocolsSorted() {
+		// Skip if already added
 		skip := false
 		for _, tried := range protocolOrder {
 			if proto.Name() == tried {
@@ -283,19 +545,11 @@ func tryPort(ctx context.Context, host string, port int, options *QueryOptions)
 				break
 			}
 		}
-		if skip {
-			continue
-		}
-
-		if info, err := queryProtocol(ctx, proto, host, port, options); err == nil {
-			if options.Debug {
-				fmt.Printf("[DEBUG] Query: SUCCESS with %s on port %d\n", proto.Name(), port)
-			}
-			return info, nil
+		if !skip {
+			protocols = append(protocols, proto)
 		}
 	}
-
-	return nil, fmt.Errorf("no protocol worked on port %d", port)
+	return protocols
 }
 
 // queryProtocol queries a specific protocol on a host:port
@@ -305,12 +559,30 @@ func queryProtocol(ctx context.Context, proto protocol.Protocol, host string, po
 
 	// Create protocol options
 	protoOpts := &protocol.Options{
-		Timeout: options.Timeout,
-		Players: options.Players,
-		Debug:   options.Debug,
+		Timeout:         options.Timeout,
+		Players:         options.Players,
+		Debug:           options.Debug,
+		Logger:          options.Logger,
+		Capabilities:    options.Capabilities,
+		Retries:         options.Retries,
+		ParallelQueries: options.ParallelQueries,
+		PingSamples:     options.PingSamples,
+		HTTPTimeout:     options.HTTPTimeout,
+		MaxResponseSize: options.MaxResponseSize,
+		Rules:           options.Rules,
+		TLSConfig:       options.TLSConfig,
+		MaxRedirects:    options.MaxRedirects,
+		QueryID:         options.queryID,
+		QueryTarget:     options.queryTarget,
+	}
+	if options.DNSCache != nil {
+		protoOpts.LookupHost = options.DNSCache.LookupHost
+	}
+	if options.Timings {
+		protoOpts.Timings = &protocol.Timings{}
 	}
 
-	info, err := proto.Query(ctx, addr, protoOpts)
+	info, err := callProtocol(ctx, proto, addr, protoOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -320,18 +592,81 @@ func queryProtocol(ctx context.Context, proto protocol.Protocol, host string, po
 	}
 
 	// Set common fields
+	info.QueriedAt = time.Now()
 	info.Address = host
 	info.Port = port
 	info.QueryPort = port
+	// Game names don't always identify the protocol, e.g. the many games answering A2S.
+	// Extra is non-nil from here on.
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["matched_protocol"] = proto.Name()
+	// Port is the connect port, so prefer a game port the server reported over the queried one
+	if gamePort, err := strconv.Atoi(info.Extra["game_port"]); err == nil && gamePort > 0 {
+		info.Port = gamePort
+	}
 	if info.Ping == 0 {
 		info.Ping = int(math.Ceil(float64(time.Since(start).Nanoseconds()) / 1e6))
 	}
 
+	if options.RconPassword != "" {
+		if rconPort := rconPortFor(proto.Name(), info, options.RconPort); rconPort > 0 {
+			applyRcon(ctx, info, host, rconPort, protoOpts, options)
+		}
+	}
+
+	if options.Timings {
+		// The protocols measure their ping from request to response, excluding connection setup
+		dns, connect := protoOpts.Timings.Durations()
+		info.Extra["dns_ms"] = strconv.FormatInt(dns.Milliseconds(), 10)
+		info.Extra["connect_ms"] = strconv.FormatInt(connect.Milliseconds(), 10)
+		info.Extra["response_ms"] = strconv.Itoa(info.Ping)
+	}
+	if options.UnknownGameName != nil {
+		applyUnknownGameName(info, proto.Name(), options.UnknownGameName)
+	}
+	if len(options.ExpectedAppIDs) > 0 {
+		flagUnexpectedAppID(info, options.ExpectedAppIDs)
+	}
+	if options.ForceGame != "" {
+		info.Extra["detected_game"] = info.Game
+		info.Game = options.ForceGame
+	}
+	if options.SanityChecks {
+		applySanityChecks(info)
+	}
+	if options.StripColorCodes {
+		stripColorCodes(info, proto.Name())
+	}
+	if options.RegionFromName {
+		applyRegionFromName(info)
+	}
+	if options.DedupPlayers {
+		dedupPlayers(info)
+	}
+	if options.ExcludeBots {
+		excludeBots(info)
+	}
+	if options.Scoreboard {
+		sortScoreboard(info.Players.List)
+	}
+	if options.PlayerLimit > 0 {
+		limitPlayers(info, options.PlayerLimit)
+	}
+	if options.MaxNameLength > 0 {
+		truncateNames(info, options.MaxNameLength)
+	}
+	if options.StateHash {
+		info.Extra["state_hash"] = stateHash(info)
+	}
+
 	return info, nil
 }
 
 // parseAddress parses an address string and returns host, port
 func parseAddress(addr string, optPort int) (string, int, error) {
+	addr = strings.TrimSpace(addr)
 	if addr == "" {
 		return "", 0, fmt.Errorf("address cannot be empty")
 	}
@@ -346,9 +681,16 @@ func parseAddress(addr string, optPort int) (string, int, error) {
 		} else {
 			host = addr
 		}
+		if err := validateHost(host); err != nil {
+			return "", 0, err
+		}
 		return host, optPort, nil
 	}
 
+	if err := validateHost(host); err != nil {
+		return "", 0, err
+	}
+
 	// Port was specified, parse it
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
@@ -358,6 +700,17 @@ func parseAddress(addr string, optPort int) (string, int, error) {
 	return host, port, nil
 }
 
+// validateHost rejects hosts that could only fail later with a confusing dial error
+func validateHost(host string) error {
+	if host == "" {
+		return fmt.Errorf("host cannot be empty")
+	}
+	if strings.IndexFunc(host, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("host cannot contain whitespace: %q", host)
+	}
+	return nil
+}
+
 // Utility functions
 
 // SupportedGames returns a list of supported game protocols including aliases
@@ -367,16 +720,16 @@ func SupportedGames() []string {
 
 // DefaultPort returns the default port for a game
 func DefaultPort(game string) int {
-	if proto, exists := protocol.GetProtocol(game); exists {
-		return proto.DefaultPort()
+	if gameConfig, _, exists := protocol.GetGameConfigFromRegistry(game); exists {
+		return gameConfig.GamePort
 	}
 	return 0
 }
 
 // DefaultQueryPort returns the default query port for a game
 func DefaultQueryPort(game string) int {
-	if proto, exists := protocol.GetProtocol(game); exists {
-		return proto.DefaultQueryPort()
+	if gameConfig, _, exists := protocol.GetGameConfigFromRegistry(game); exists {
+		return gameConfig.QueryPort
 	}
 	return 0
 }
@@ -397,7 +750,9 @@ func WithPort(port int) Option {
 	}
 }
 
-// WithTimeout sets the query timeout
+// WithTimeout sets the query timeout. It bounds each exchange with the server, not the
+// whole query: an A2S query with players and rules can take up to twice d, so use a
+// context deadline for a hard limit.
 func WithTimeout(d time.Duration) Option {
 	return func(o *QueryOptions) {
 		o.Timeout = d
@@ -414,11 +769,17 @@ func WithPlayers() Option {
 // WithPortRange specifies a range of ports to scan
 func WithPortRange(start, end int) Option {
 	return func(o *QueryOptions) {
-		ports := make([]int, 0, end-start+1)
-		for port := start; port <= end; port++ {
-			ports = append(ports, port)
+		o.PortRange = portRange(start, end)
+	}
+}
+
+// WithPortPreset scans the ports of a named preset such as "steam" or "minecraft",
+// unknown presets are ignored so check them with PortPreset first
+func WithPortPreset(name string) Option {
+	return func(o *QueryOptions) {
+		if ports, exists := PortPreset(name); exists {
+			o.PortRange = ports
 		}
-		o.PortRange = ports
 	}
 }
 
@@ -429,13 +790,282 @@ func WithPorts(ports []int) Option {
 	}
 }
 
-// WithMaxConcurrency limits concurrent queries
+// WithMaxConcurrency limits concurrent queries in discovery and QueryBatch. Zero or less
+// means auto: 16 per CPU, capped at 256 and at the number of queries to run.
 func WithMaxConcurrency(max int) Option {
 	return func(o *QueryOptions) {
 		o.MaxConcurrency = max
 	}
 }
 
+// WithCapabilities records which follow-up queries the server likely supports in info.Extra
+func WithCapabilities() Option {
+	return func(o *QueryOptions) {
+		o.Capabilities = true
+	}
+}
+
+// WithMaxResults stops discovery once n servers have been found (0 = no limit)
+func WithMaxResults(n int) Option {
+	return func(o *QueryOptions) {
+		o.MaxResults = n
+	}
+}
+
+// WithRetries allows protocols that support it to retry transient failures up to n more times
+func WithRetries(n int) Option {
+	return func(o *QueryOptions) {
+		o.Retries = n
+	}
+}
+
+// WithSanityChecks clamps implausible player counts and flags them in info.Extra["sanity_warnings"]
+func WithSanityChecks() Option {
+	return func(o *QueryOptions) {
+		o.SanityChecks = true
+	}
+}
+
+// WithPlayerLimit keeps at most n entries in the player list (0 = unlimited), after bots,
+// duplicates and scoreboard ordering have been applied, and sets
+// info.Extra["player_list_truncated"] when entries were dropped. Players.Current still
+// reports the full count. None of the supported protocols page their player lists, so the
+// full list is fetched and then cut.
+func WithPlayerLimit(n int) Option {
+	return func(o *QueryOptions) {
+		o.PlayerLimit = n
+	}
+}
+
+// WithMaxNameLength truncates the server name and player names to n runes (0 = unlimited)
+func WithMaxNameLength(n int) Option {
+	return func(o *QueryOptions) {
+		o.MaxNameLength = n
+	}
+}
+
+// WithParallelSubQueries runs sub-queries such as the player list and rules concurrently with
+// the info query on separate sockets, trading a socket per sub-query for fewer sequential round trips
+func WithParallelSubQueries() Option {
+	return func(o *QueryOptions) {
+		o.ParallelQueries = true
+	}
+}
+
+// WithOnlineOnly omits offline entries from QueryBatch results
+func WithOnlineOnly() Option {
+	return func(o *QueryOptions) {
+		o.OnlineOnly = true
+	}
+}
+
+// WithPingSamples takes n latency samples within one query, reporting the median in
+// info.Ping and the spread in info.Extra["ping_min"] and info.Extra["ping_max"]
+func WithPingSamples(n int) Option {
+	return func(o *QueryOptions) {
+		o.PingSamples = n
+	}
+}
+
+// WithForceGame reports game as info.Game regardless of detection, keeping the
+// detected value in info.Extra["detected_game"]
+func WithForceGame(game string) Option {
+	return func(o *QueryOptions) {
+		o.ForceGame = game
+	}
+}
+
+// WithDNSCache resolves each hostname at most once per ttl across every query made with
+// the same options, such as a QueryBatch or repeated discovery scans of one host
+func WithDNSCache(ttl time.Duration) Option {
+	cache := newDNSCache(ttl)
+	return func(o *QueryOptions) {
+		o.DNSCache = cache
+	}
+}
+
+// WithHTTPTimeout sets the timeout for HTTP-based queries such as the Terraria TShock
+// and Assetto Corsa APIs, which otherwise share the main timeout
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(o *QueryOptions) {
+		o.HTTPTimeout = d
+	}
+}
+
+// WithTLSConfig makes HTTP-based queries such as the Terraria TShock and Assetto Corsa APIs
+// try HTTPS first using cfg, for example with InsecureSkipVerify for self-signed certificates.
+// Without it HTTPS is still tried with the default config when a server rejects plain HTTP.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *QueryOptions) {
+		o.TLSConfig = cfg
+	}
+}
+
+// WithScanEventCallback calls fn after each protocol attempt on a port during discovery
+// and auto-detection, with the port, protocol, outcome and latency, for frontends that
+// show a scan probe by probe. Discovery may call fn from several goroutines at once.
+func WithScanEventCallback(fn func(ScanEvent)) Option {
+	return func(o *QueryOptions) {
+		o.ScanEvents = fn
+	}
+}
+
+// WithTimingHistogram buckets probe latencies into the ScanReport returned by
+// DiscoverServersWithReport, for picking concurrency and timeout values for a network
+func WithTimingHistogram() Option {
+	return func(o *QueryOptions) {
+		o.TimingHistogram = true
+	}
+}
+
+// WithMaxRedirects lets HTTP-based protocols follow up to n redirects. By default none are
+// followed: a game server's API should answer directly, and an untrusted server could
+// otherwise redirect queries to internal endpoints.
+func WithMaxRedirects(n int) Option {
+	return func(o *QueryOptions) {
+		o.MaxRedirects = n
+	}
+}
+
+// WithMaxResponseSize caps how many bytes are read from a single response. Raw probes
+// default to 65535 bytes and TCP or HTTP protocol responses to 2 MiB.
+func WithMaxResponseSize(n int) Option {
+	return func(o *QueryOptions) {
+		o.MaxResponseSize = n
+	}
+}
+
+// WithStripColorCodes removes color and formatting codes from player names too. The
+// built-in protocols always clean server names, keeping the raw name in
+// info.Extra["name_raw"]; this extends that to names from custom protocols.
+func WithStripColorCodes() Option {
+	return func(o *QueryOptions) {
+		o.StripColorCodes = true
+	}
+}
+
+// WithTimings records the DNS, connect and response durations of the query in
+// info.Extra["dns_ms"], info.Extra["connect_ms"] and info.Extra["response_ms"]
+func WithTimings() Option {
+	return func(o *QueryOptions) {
+		o.Timings = true
+	}
+}
+
+// WithRules queries the server's rules (cvars) into info.Rules where the protocol supports it.
+// Most servers only expose their next map as a rule, so this also fills
+// info.Extra["next_map"] where it is found; without rules only the keywords are checked.
+func WithRules() Option {
+	return func(o *QueryOptions) {
+		o.Rules = true
+	}
+}
+
+// WithStateHash records a hash of the server's name, map, version and player counts in
+// info.Extra["state_hash"], so pollers can detect changes by comparing one value
+func WithStateHash() Option {
+	return func(o *QueryOptions) {
+		o.StateHash = true
+	}
+}
+
+// WithIncludeOffline makes discovery of explicitly given ports, through the address, WithPort,
+// WithPorts, WithPortRange or a preset, return an offline entry with the failure in
+// info.Extra["error"] for each port that didn't answer, turning the scan into an up/down
+// report. Scans of the common ports are unaffected.
+func WithIncludeOffline() Option {
+	return func(o *QueryOptions) {
+		o.IncludeOffline = true
+	}
+}
+
+// WithReportUnknownListeners makes discovery report ports that accept TCP connections
+// but match no known protocol, as offline entries with Game "unknown" and
+// info.Extra["listener"] = "tcp"
+func WithReportUnknownListeners() Option {
+	return func(o *QueryOptions) {
+		o.ReportListeners = true
+	}
+}
+
+// WithDedupPlayers drops repeated player names from Players.List, keeping the first entry.
+// Proxies aggregating several backends can list the same player more than once; it is
+// opt-in because separate players can share a display name on ordinary servers.
+func WithDedupPlayers() Option {
+	return func(o *QueryOptions) {
+		o.DedupPlayers = true
+	}
+}
+
+// WithUnknownGameName names games the library doesn't recognize. When detection falls back
+// to the generic protocol name, fn is called with the Steam App ID and game description the
+// server reported; a non-empty result becomes info.Game. Discovery and QueryBatch may call
+// fn from several goroutines at once.
+func WithUnknownGameName(fn func(appID int, desc string) string) Option {
+	return func(o *QueryOptions) {
+		o.UnknownGameName = fn
+	}
+}
+
+// WithExpectedAppIDs names the Steam App IDs a host is expected to run, for hosting panels
+// that provision a fixed catalog of games. Auto-detection tries the Source query first on
+// each port, and responses reporting an App ID outside the set get
+// Extra["unexpected_app_id"] = "true".
+func WithExpectedAppIDs(appIDs []int) Option {
+	return func(o *QueryOptions) {
+		o.ExpectedAppIDs = make(map[int]bool, len(appIDs))
+		for _, id := range appIDs {
+			o.ExpectedAppIDs[id] = true
+		}
+	}
+}
+
+// WithRcon authenticates to the server's RCON with password after a successful Source or
+// Minecraft query and takes the name, map and full player list, with player addresses,
+// from its status. port 0 uses the game port for Source and 25575 for Minecraft. RCON
+// failures leave the public query's data in place and set Extra["rcon_error"].
+func WithRcon(password string, port int) Option {
+	return func(o *QueryOptions) {
+		o.RconPassword = password
+		o.RconPort = port
+	}
+}
+
+// WithScoreboard sorts Players.List for display as a scoreboard: grouped by team where the
+// protocol reports one, then by descending score. Players without a team come last.
+func WithScoreboard() Option {
+	return func(o *QueryOptions) {
+		o.Scoreboard = true
+	}
+}
+
+// WithExcludeBots removes bot-flagged players from Players.List and records the human
+// player count in info.Extra["human_players"], using the bot count where the protocol
+// reports one (A2S). Players.Current still includes bots.
+func WithExcludeBots() Option {
+	return func(o *QueryOptions) {
+		o.ExcludeBots = true
+	}
+}
+
+// WithRegionFromName fills an empty Region from a region tag in the server name, such as
+// "[US-East]" or "EU | ...". It is a heuristic based on what admins declare, not on where
+// the IP is located.
+func WithRegionFromName() Option {
+	return func(o *QueryOptions) {
+		o.RegionFromName = true
+	}
+}
+
+// WithPlayerHistory sets how many player count samples an Engine keeps per server for
+// Engine.PlayerHistory (default 60, 0 disables it). Memory grows with n times the number of
+// addresses queried. It has no effect outside an Engine.
+func WithPlayerHistory(n int) Option {
+	return func(o *QueryOptions) {
+		o.PlayerHistory = n
+	}
+}
+
 // WithDebug enables debug logging
 func WithDebug() Option {
 	return func(o *QueryOptions) {
@@ -443,3 +1073,41 @@ func WithDebug() Option {
 	}
 }
 
+// WithLogger enables debug logging and routes it to the given logger at slog.LevelDebug
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *QueryOptions) {
+		o.Debug = true
+		o.Logger = logger
+	}
+}
+
+// debugLogf writes a debug message to the configured logger, or stderr when none is set
+func debugLogf(options *QueryOptions, component, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if options.Logger != nil {
+		if options.queryID != "" {
+			options.Logger.Debug(message, "component", component, "query_id", options.queryID, "target", options.queryTarget)
+			return
+		}
+		options.Logger.Debug(message, "component", component)
+		return
+	}
+	if options.queryID != "" {
+		component = fmt.Sprintf("%s (%s %s)", component, options.queryID, options.queryTarget)
+	}
+	fmt.Fprintf(os.Stderr, "[DEBUG %s] %s: %s\n", time.Now().Format("15:04:05.000"), component, message)
+}
+
+// lastQueryID numbers queries for debug correlation
+var lastQueryID atomic.Uint64
+
+// tagQuery gives options a new query ID for target, so the debug lines of concurrent
+// queries can be told apart. It does nothing without debug logging.
+func tagQuery(options *QueryOptions, target string) {
+	if !options.Debug {
+		return
+	}
+	options.queryID = "q" + strconv.FormatUint(lastQueryID.Add(1), 10)
+	options.queryTarget = target
+}
+
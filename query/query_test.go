@@ -0,0 +1,378 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// startA2SResponder starts a UDP server answering every A2S_INFO request and returns its port.
+func startA2SResponder(t *testing.T, name string, appID uint16) int {
+	return startA2SResponderWithGamePort(t, name, appID, 0)
+}
+
+// startA2SResponderWithGamePort is startA2SResponder with the game port reported in the
+// extra data field, or no extra data when gamePort is 0.
+func startA2SResponderWithGamePort(t *testing.T, name string, appID uint16, gamePort uint16) int {
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start mock server: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	var response bytes.Buffer
+	response.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x49, 0x11})
+	for _, field := range []string{name, "de_dust2", "csgo", "Counter-Strike"} {
+		response.WriteString(field)
+		response.WriteByte(0)
+	}
+	response.Write([]byte{byte(appID), byte(appID >> 8), 1, 10, 0, 'd', 'l', 0, 0})
+	response.WriteString("1.0")
+	response.WriteByte(0)
+	if gamePort != 0 {
+		response.Write([]byte{0x80, byte(gamePort), byte(gamePort >> 8)})
+	}
+
+	go func() {
+		buffer := make([]byte, 1400)
+		for {
+			n, addr, err := l.ReadFrom(buffer)
+			if err != nil {
+				return // Listener closed
+			}
+			if n >= 5 && buffer[4] == 0x54 {
+				l.WriteTo(response.Bytes(), addr)
+			}
+		}
+	}()
+
+	return l.LocalAddr().(*net.UDPAddr).Port
+}
+
+// startSilentHost binds a TCP and UDP port that accept traffic but never answer and returns the port.
+func startSilentHost(t *testing.T) int {
+	for {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to start silent TCP listener: %v", err)
+		}
+		port := tcp.Addr().(*net.TCPAddr).Port
+		udp, err := net.ListenPacket("udp", tcp.Addr().String())
+		if err != nil {
+			tcp.Close()
+			continue // UDP port taken, try another
+		}
+		t.Cleanup(func() {
+			tcp.Close()
+			udp.Close()
+		})
+		return port
+	}
+}
+
+func TestQuery_AutoDetectOfflineHostIsBounded(t *testing.T) {
+	original := commonPorts
+	commonPorts = []int{startSilentHost(t)}
+	defer func() { commonPorts = original }()
+
+	start := time.Now()
+	_, err := Query(context.Background(), "127.0.0.1", WithTimeout(5*time.Second))
+	elapsed := time.Since(start)
+
+	// Each registered protocol gets at most one bounded attempt on the silent port
+	bound := time.Duration(len(protocol.AllProtocols())+1) * autoDetectAttemptTimeout
+	assert.Error(t, err)
+	assert.Less(t, elapsed, bound)
+}
+
+func TestQuery_AutoDetectOfflinePortIsBounded(t *testing.T) {
+	original := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = original }()
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(startSilentHost(t)))
+
+	start := time.Now()
+	_, err := Query(context.Background(), addr, WithTimeout(5*time.Second))
+	elapsed := time.Since(start)
+
+	// A given port gets the same bounded attempts as the common ports
+	bound := time.Duration(len(protocol.AllProtocols())+1) * autoDetectAttemptTimeout
+	assert.Error(t, err)
+	assert.Less(t, elapsed, bound)
+}
+
+func TestDiscoverServers_MaxResults(t *testing.T) {
+	ports := []int{
+		startA2SResponder(t, "Server 1", 730),
+		startA2SResponder(t, "Server 2", 730),
+		startA2SResponder(t, "Server 3", 730),
+	}
+
+	servers, err := DiscoverServers(context.Background(), "127.0.0.1",
+		WithPorts(ports), WithTimeout(time.Second), WithMaxConcurrency(1))
+	assert.NoError(t, err)
+	assert.Len(t, servers, 3)
+
+	servers, err = DiscoverServers(context.Background(), "127.0.0.1",
+		WithPorts(ports), WithTimeout(time.Second), WithMaxConcurrency(1), WithMaxResults(1))
+	assert.NoError(t, err)
+	assert.Len(t, servers, 1)
+}
+
+func TestQuery_MatchedProtocol(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	port := startA2SResponder(t, "Matched Server", 440)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	// A game alias, auto-detection and discovery all report the registry protocol
+	info, err := Query(context.Background(), addr, WithGame("team-fortress-2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a2s", info.Extra["matched_protocol"])
+
+	info, err = Query(context.Background(), addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "team-fortress-2", info.Game)
+	assert.Equal(t, "a2s", info.Extra["matched_protocol"])
+
+	servers, err := DiscoverServers(context.Background(), "127.0.0.1", WithPorts([]int{port}))
+	assert.NoError(t, err)
+	if assert.Len(t, servers, 1) {
+		assert.Equal(t, "a2s", servers[0].Extra["matched_protocol"])
+	}
+}
+
+func TestQuery_QueriedAt(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	port := startA2SResponder(t, "Timestamped Server", 440)
+	before := time.Now()
+	info, err := Query(context.Background(), net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	assert.NoError(t, err)
+	assert.False(t, info.QueriedAt.Before(before))
+	assert.False(t, info.QueriedAt.After(time.Now()))
+
+	// Offline results from a batch are stamped too
+	results := QueryBatch(context.Background(), []string{net.JoinHostPort("127.0.0.1", strconv.Itoa(closedPort(t)))}, WithTimeout(200*time.Millisecond))
+	if assert.Len(t, results, 1) {
+		assert.False(t, results[0].QueriedAt.Before(before))
+	}
+}
+
+func TestQuery_ForceGame(t *testing.T) {
+	port := startA2SResponder(t, "Modded Server", 730)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	info, err := Query(context.Background(), addr, WithGame("a2s"), WithForceGame("my-total-conversion"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "my-total-conversion", info.Game)
+	assert.Equal(t, "counter-strike", info.Extra["detected_game"])
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		optPort  int
+		wantHost string
+		wantPort int
+		wantErr  string
+	}{
+		{"host and port", "example.com:25565", 0, "example.com", 25565, ""},
+		{"host uses option port", "example.com", 27015, "example.com", 27015, ""},
+		{"surrounding whitespace", "  example.com:25565\n", 0, "example.com", 25565, ""},
+		{"trailing dot FQDN", "example.com.:25565", 0, "example.com.", 25565, ""},
+		{"bracketed IPv6", "[::1]", 25565, "::1", 25565, ""},
+		{"empty", "", 0, "", 0, "address cannot be empty"},
+		{"whitespace only", " ", 0, "", 0, "address cannot be empty"},
+		{"missing host", ":25565", 0, "", 0, "host cannot be empty"},
+		{"host with space", "host with space:25565", 0, "", 0, "host cannot contain whitespace"},
+		{"invalid port", "example.com:abc", 0, "", 0, "invalid port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := parseAddress(tt.addr, tt.optPort)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantPort, port)
+		})
+	}
+}
+
+func TestDefaultPorts_UseGameConfig(t *testing.T) {
+	assert.Equal(t, 7787, DefaultPort("squad"))
+	assert.Equal(t, 27165, DefaultQueryPort("squad"))
+	assert.Equal(t, 27015, DefaultQueryPort("a2s"))
+	assert.Equal(t, 0, DefaultQueryPort("not-a-game"))
+
+	// Discovery and auto-detection seed the Squad query port
+	assert.Contains(t, commonPorts, 27165)
+}
+
+func TestQuery_Timings(t *testing.T) {
+	port := startA2SResponder(t, "Timed Server", 440)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	info, err := Query(context.Background(), addr, WithGame("a2s"), WithTimings())
+
+	assert.NoError(t, err)
+	for _, key := range []string{"dns_ms", "connect_ms", "response_ms"} {
+		value, err := strconv.Atoi(info.Extra[key])
+		assert.NoError(t, err, key)
+		assert.GreaterOrEqual(t, value, 0, key)
+	}
+	assert.Equal(t, strconv.Itoa(info.Ping), info.Extra["response_ms"])
+}
+
+func TestDiscoverServers_DebugLinesTaggedPerQuery(t *testing.T) {
+	targets := []string{
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Tagged Server 1", 730))),
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Tagged Server 2", 730))),
+	}
+	var ports []int
+	for _, target := range targets {
+		_, port, _ := net.SplitHostPort(target)
+		n, _ := strconv.Atoi(port)
+		ports = append(ports, n)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	_, err := DiscoverServers(context.Background(), "127.0.0.1", WithPorts(ports), WithTimeout(time.Second), WithLogger(logger))
+	assert.NoError(t, err)
+
+	// Every protocol line names its query, and each target has a single query ID
+	ids := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record struct {
+			Component string `json:"component"`
+			QueryID   string `json:"query_id"`
+			Target    string `json:"target"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record.Component != "A2S" {
+			continue
+		}
+		assert.Contains(t, targets, record.Target)
+		if id, seen := ids[record.Target]; seen {
+			assert.Equal(t, id, record.QueryID)
+		}
+		ids[record.Target] = record.QueryID
+	}
+	assert.Len(t, ids, 2)
+	assert.NotEqual(t, ids[targets[0]], ids[targets[1]])
+}
+
+func TestTryPort_SkipsRefusedTransport(t *testing.T) {
+	port := closedPort(t)
+
+	var logs bytes.Buffer
+	options := &QueryOptions{Timeout: time.Second}
+	WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))(options)
+
+	start := time.Now()
+	_, err := tryPort(context.Background(), "127.0.0.1", port, options)
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Contains(t, logs.String(), "udp refused")
+	assert.Contains(t, logs.String(), "tcp refused")
+}
+
+func TestPortProtocols_StableOrder(t *testing.T) {
+	first := portProtocols()
+	assert.Len(t, first, len(protocol.AllProtocols()))
+	for i, name := range protocolOrder {
+		assert.Equal(t, name, first[i].Name())
+	}
+
+	// The protocols after the popular ones are sorted by name
+	rest := first[len(protocolOrder):]
+	for i := 1; i < len(rest); i++ {
+		assert.Less(t, rest[i-1].Name(), rest[i].Name())
+	}
+
+	for i := 0; i < 20; i++ {
+		again := portProtocols()
+		for j := range first {
+			assert.Equal(t, first[j].Name(), again[j].Name())
+		}
+	}
+}
+
+func TestDiscoverServers_SameGameAcrossRuns(t *testing.T) {
+	port := startA2SResponder(t, "Stable Server", 440)
+
+	var games []string
+	for i := 0; i < 5; i++ {
+		servers, err := DiscoverServers(context.Background(), "127.0.0.1", WithPorts([]int{port}), WithTimeout(200*time.Millisecond))
+		assert.NoError(t, err)
+		if assert.Len(t, servers, 1) {
+			games = append(games, servers[0].Game)
+		}
+	}
+	for _, game := range games {
+		assert.Equal(t, "team-fortress-2", game)
+	}
+}
+
+func TestQuery_PortFromReportedGamePort(t *testing.T) {
+	port := startA2SResponderWithGamePort(t, "Offset Server", 440, 27115)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	info, err := Query(context.Background(), addr, WithGame("a2s"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 27115, info.Port)
+	assert.Equal(t, port, info.QueryPort)
+	assert.Equal(t, "steam://connect/127.0.0.1:27115", info.ConnectString())
+}
+
+func TestDiscoverServers_IncludeOffline(t *testing.T) {
+	onlinePort := startA2SResponder(t, "Up Server", 730)
+	offlinePort := closedPort(t)
+	ports := []int{onlinePort, offlinePort}
+
+	servers, err := DiscoverServers(context.Background(), "127.0.0.1", WithPorts(ports), WithTimeout(500*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Len(t, servers, 1)
+
+	servers, err = DiscoverServers(context.Background(), "127.0.0.1", WithPorts(ports), WithTimeout(500*time.Millisecond),
+		WithIncludeOffline())
+	assert.NoError(t, err)
+	if assert.Len(t, servers, 2) {
+		byPort := map[int]*protocol.ServerInfo{servers[0].QueryPort: servers[0], servers[1].QueryPort: servers[1]}
+		assert.True(t, byPort[onlinePort].Online)
+		assert.False(t, byPort[offlinePort].Online)
+		assert.Equal(t, "127.0.0.1", byPort[offlinePort].Address)
+		assert.NotEmpty(t, byPort[offlinePort].Extra["error"])
+	}
+
+	// Common port scans don't report every guessed port as down
+	original := commonPorts
+	commonPorts = []int{offlinePort}
+	defer func() { commonPorts = original }()
+
+	servers, err = DiscoverServers(context.Background(), "127.0.0.1", WithTimeout(500*time.Millisecond), WithIncludeOffline())
+	assert.NoError(t, err)
+	assert.Empty(t, servers)
+}
@@ -0,0 +1,31 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// RawProbe sends payload to addr over network ("udp" or "tcp") and returns the raw bytes of
+// the first response, honoring WithTimeout, WithMaxResponseSize, WithDNSCache and debug options.
+// It is meant for exploring protocols the library doesn't support yet.
+func RawProbe(ctx context.Context, network, addr string, payload []byte, opts ...Option) ([]byte, error) {
+	options := &QueryOptions{
+		Timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	protoOpts := &protocol.Options{
+		Timeout:         options.Timeout,
+		Debug:           options.Debug,
+		Logger:          options.Logger,
+		MaxResponseSize: options.MaxResponseSize,
+	}
+	if options.DNSCache != nil {
+		protoOpts.LookupHost = options.DNSCache.LookupHost
+	}
+	return protocol.RawExchange(ctx, network, addr, payload, protoOpts)
+}
@@ -0,0 +1,38 @@
+package query
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawProbe(t *testing.T) {
+	// Echo server that answers every datagram with a fixed reply
+	l, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start echo server: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		buffer := make([]byte, 1400)
+		for {
+			n, addr, err := l.ReadFrom(buffer)
+			if err != nil {
+				return // Listener closed
+			}
+			l.WriteTo(append([]byte("reply:"), buffer[:n]...), addr)
+		}
+	}()
+
+	response, err := RawProbe(context.Background(), "udp", l.LocalAddr().String(), []byte("hello"), WithTimeout(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, "reply:hello", string(response))
+
+	// The size cap truncates the read
+	response, err = RawProbe(context.Background(), "udp", l.LocalAddr().String(), []byte("hello"), WithTimeout(time.Second), WithMaxResponseSize(5))
+	assert.NoError(t, err)
+	assert.Equal(t, "reply", string(response))
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// minecraftRconPort is the rcon.port default in server.properties
+const minecraftRconPort = 25575
+
+// rconPortFor returns the RCON port for a server found by protoName, or 0 when its protocol
+// has no RCON. Source RCON listens on TCP at the game port.
+func rconPortFor(protoName string, info *protocol.ServerInfo, port int) int {
+	switch protoName {
+	case "a2s":
+		if port > 0 {
+			return port
+		}
+		return info.Port
+	case "minecraft", "minecraft-query":
+		if port > 0 {
+			return port
+		}
+		return minecraftRconPort
+	}
+	return 0
+}
+
+// applyRcon replaces the public query's name, map and players with the RCON status, which
+// lists every player with their address. A failed RCON query keeps the public data and
+// records the reason in Extra["rcon_error"].
+func applyRcon(ctx context.Context, info *protocol.ServerInfo, host string, port int, protoOpts *protocol.Options, options *QueryOptions) {
+	rconOpts := *protoOpts
+	rconOpts.RconPassword = options.RconPassword
+	rconOpts.Timings = nil
+
+	rcon := &protocol.RconProtocol{}
+	status, err := rcon.Query(ctx, net.JoinHostPort(host, strconv.Itoa(port)), &rconOpts)
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	if err != nil {
+		if options.Debug {
+			debugLogf(options, "Query", "RCON status on port %d failed: %v", port, err)
+		}
+		info.Extra["rcon_error"] = err.Error()
+		return
+	}
+
+	if status.Name != "" {
+		info.Name = status.Name
+	}
+	if status.Map != "" {
+		info.Map = status.Map
+	}
+	info.Players.Current = status.Players.Current
+	if status.Players.Max > 0 {
+		info.Players.Max = status.Players.Max
+	}
+	info.Players.List = status.Players.List
+	info.Extra["rcon"] = "true"
+}
@@ -0,0 +1,32 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery_RconFailureKeepsPublicData(t *testing.T) {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(startA2SResponder(t, "Public Server", 440)))
+
+	info, err := Query(context.Background(), addr, WithGame("a2s"), WithTimeout(500*time.Millisecond), WithRcon("secret", closedPort(t)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Public Server", info.Name)
+	assert.NotEmpty(t, info.Extra["rcon_error"])
+	assert.NotContains(t, info.Extra, "rcon")
+}
+
+func TestRconPortFor(t *testing.T) {
+	info := &protocol.ServerInfo{Port: 27015}
+
+	assert.Equal(t, 27015, rconPortFor("a2s", info, 0))
+	assert.Equal(t, 27020, rconPortFor("a2s", info, 27020))
+	assert.Equal(t, 25575, rconPortFor("minecraft", info, 0))
+	assert.Equal(t, 0, rconPortFor("terraria", info, 0))
+}
@@ -0,0 +1,33 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// callProtocol runs proto.Query, turning a panic or a nil result without an error into
+// an error so a buggy registered protocol fails its own attempt instead of crashing a
+// whole scan. Panics in goroutines the protocol starts itself can't be recovered here.
+func callProtocol(ctx context.Context, proto protocol.Protocol, addr string, opts *protocol.Options) (info *protocol.ServerInfo, err error) {
+	defer recoverProtocolPanic(proto.Name(), &err)
+	info, err = proto.Query(ctx, addr, opts)
+	if info == nil && err == nil {
+		err = fmt.Errorf("protocol %s returned no server info", proto.Name())
+	}
+	return info, err
+}
+
+// probeProtocol is callProtocol for Prober.Probe
+func probeProtocol(ctx context.Context, prober protocol.Prober, name, addr string, opts *protocol.Options) (err error) {
+	defer recoverProtocolPanic(name, &err)
+	return prober.Probe(ctx, addr, opts)
+}
+
+// recoverProtocolPanic stores a recovered panic from protocol name in err
+func recoverProtocolPanic(name string, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("protocol %s panicked: %v", name, r)
+	}
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+// panickyProtocol is a broken third-party protocol that panics on every call
+type panickyProtocol struct{}
+
+func (p *panickyProtocol) Name() string          { return "zz-panicky" }
+func (p *panickyProtocol) DefaultPort() int      { return 1 }
+func (p *panickyProtocol) DefaultQueryPort() int { return 1 }
+func (p *panickyProtocol) Games() []protocol.GameConfig {
+	return []protocol.GameConfig{{Name: "zz-panicky", GamePort: 1, QueryPort: 1}}
+}
+func (p *panickyProtocol) DetectGame(info *protocol.ServerInfo) string { return "zz-panicky" }
+func (p *panickyProtocol) Query(ctx context.Context, addr string, opts *protocol.Options) (*protocol.ServerInfo, error) {
+	panic("index out of range")
+}
+func (p *panickyProtocol) Probe(ctx context.Context, addr string, opts *protocol.Options) error {
+	panic("nil map")
+}
+
+// nilProtocol is a broken third-party protocol that reports neither a server nor an error
+type nilProtocol struct{ panickyProtocol }
+
+func (p *nilProtocol) Name() string { return "zz-nil" }
+func (p *nilProtocol) Games() []protocol.GameConfig {
+	return []protocol.GameConfig{{Name: "zz-nil", GamePort: 1, QueryPort: 1}}
+}
+func (p *nilProtocol) Query(ctx context.Context, addr string, opts *protocol.Options) (*protocol.ServerInfo, error) {
+	return nil, nil
+}
+
+func TestDiscoverServers_PanickingProtocol(t *testing.T) {
+	protocol.Register(&panickyProtocol{})
+	protocol.Register(&nilProtocol{})
+	t.Cleanup(func() {
+		protocol.Unregister("zz-panicky")
+		protocol.Unregister("zz-nil")
+	})
+
+	serverPort := startA2SResponder(t, "Survivor", 440)
+	deadPort := closedPort(t)
+
+	var mu sync.Mutex
+	var panicked, empty []ScanEvent
+	record := func(event ScanEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch event.Protocol {
+		case "zz-panicky":
+			panicked = append(panicked, event)
+		case "zz-nil":
+			empty = append(empty, event)
+		}
+	}
+
+	servers, err := DiscoverServers(context.Background(), "127.0.0.1",
+		WithPorts([]int{serverPort, deadPort}), WithTimeout(500*time.Millisecond), WithScanEventCallback(record))
+
+	assert.NoError(t, err)
+	if assert.Len(t, servers, 1) {
+		assert.Equal(t, "Survivor", servers[0].Name)
+	}
+	if assert.Len(t, panicked, 1) {
+		assert.Equal(t, ScanError, panicked[0].Result)
+		assert.ErrorContains(t, panicked[0].Err, "panicked: index out of range")
+	}
+	if assert.Len(t, empty, 1) {
+		assert.Equal(t, ScanError, empty[0].Result)
+		assert.ErrorContains(t, empty[0].Err, "returned no server info")
+	}
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(deadPort))
+	_, err = Query(context.Background(), addr, WithGame("zz-panicky"), WithTimeout(200*time.Millisecond))
+	assert.Error(t, err)
+	_, err = Query(context.Background(), addr, WithGame("zz-nil"), WithTimeout(200*time.Millisecond))
+	assert.Error(t, err)
+
+	online, err := IsOnline(context.Background(), "zz-panicky", addr)
+	assert.False(t, online)
+	assert.ErrorContains(t, err, "panicked: nil map")
+}
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// nameTagPattern matches the bracketed or parenthesized tags communities put in server names
+var nameTagPattern = regexp.MustCompile(`[\[(]([^\])]{1,16})[\])]`)
+
+// regionTokenPattern matches a whole tag naming a region or country, optionally with a
+// direction such as "US-East" or "EU West"
+var regionTokenPattern = regexp.MustCompile(`(?i)^(na|sa|us|usa|eu|asia|oce|au|aus|nz|uk|de|fr|nl|pl|se|fi|ru|br|jp|kr|cn|sg|hk|ca|za|me)(?:[-_ ]?(east|west|central|north|south))?$`)
+
+// applyRegionFromName fills an empty Region from a region tag in the server name, such as
+// "[US-East]" or "| EU |". Only whole bracketed or pipe-separated tags are considered, so
+// ordinary words in the name aren't mistaken for country codes.
+func applyRegionFromName(info *protocol.ServerInfo) {
+	if info.Region != "" {
+		return
+	}
+
+	var candidates []string
+	for _, match := range nameTagPattern.FindAllStringSubmatch(info.Name, -1) {
+		candidates = append(candidates, match[1])
+	}
+	if strings.Contains(info.Name, "|") {
+		candidates = append(candidates, strings.Split(info.Name, "|")...)
+	}
+
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if regionTokenPattern.MatchString(candidate) {
+			info.Region = strings.ToLower(strings.NewReplacer("_", "-", " ", "-").Replace(candidate))
+			return
+		}
+	}
+}
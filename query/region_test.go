@@ -0,0 +1,33 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRegionFromName(t *testing.T) {
+	tests := []struct {
+		name   string
+		server string
+		region string
+		want   string
+	}{
+		{"bracketed region with direction", "[US-East] Community Surf", "", "us-east"},
+		{"parenthesized country", "Dust2 Only (DE)", "", "de"},
+		{"pipe separated", "EU | 24/7 Dust2 | FastDL", "", "eu"},
+		{"space separated direction", "[EU West] Retakes", "", "eu-west"},
+		{"plain words are ignored", "Come as you are", "", ""},
+		{"unknown tags are ignored", "[VIP] [128 tick] Server", "", ""},
+		{"declared region is kept", "[US-East] Server", "eu", "eu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &protocol.ServerInfo{Name: tt.server, Region: tt.region}
+			applyRegionFromName(info)
+			assert.Equal(t, tt.want, info.Region)
+		})
+	}
+}
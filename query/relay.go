@@ -0,0 +1,24 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPossiblyRelayOnly is wrapped into the error of a failed query for a game whose servers
+// can run behind Steam Datagram Relay. Such servers have no public address to query, so
+// only those hosted in direct-IP mode answer.
+var ErrPossiblyRelayOnly = errors.New("server may be reachable only through Steam Datagram Relay")
+
+// relayGames are the games that can host through Steam Datagram Relay
+var relayGames = map[string]bool{
+	"barotrauma": true,
+}
+
+// relayHint wraps ErrPossiblyRelayOnly into err when game can host through the relay
+func relayHint(game string, err error) error {
+	if !relayGames[game] {
+		return err
+	}
+	return fmt.Errorf("%w (%w)", err, ErrPossiblyRelayOnly)
+}
@@ -0,0 +1,34 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery_RelayHint(t *testing.T) {
+	savedPorts := commonPorts
+	commonPorts = nil
+	defer func() { commonPorts = savedPorts }()
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(closedPort(t)))
+
+	_, err := Query(context.Background(), addr, WithGame("barotrauma"), WithTimeout(200*time.Millisecond))
+	assert.ErrorIs(t, err, ErrPossiblyRelayOnly)
+
+	// Games that always expose a query port fail plainly
+	_, err = Query(context.Background(), addr, WithGame("rust"), WithTimeout(200*time.Millisecond))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrPossiblyRelayOnly))
+
+	results := QueryBatch(context.Background(), []string{addr}, WithGame("barotrauma"), WithTimeout(200*time.Millisecond))
+	if assert.Len(t, results, 1) {
+		assert.False(t, results[0].Online)
+		assert.Equal(t, "true", results[0].Extra["possibly_relay_only"])
+	}
+}
@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// ScanReport summarizes a discovery run, for tuning concurrency and timeouts to a network
+type ScanReport struct {
+	PortsScanned int             `json:"ports_scanned"`
+	Probes       int             `json:"probes"`              // Protocol attempts made, not counting skipped ones
+	Duration     time.Duration   `json:"duration"`            // Wall time of the whole scan
+	Histogram    []LatencyBucket `json:"histogram,omitempty"` // Probe latencies, only with WithTimingHistogram
+}
+
+// LatencyBucket counts the probes that took at least the previous bucket's Below and
+// less than this one's. The last bucket has a Below of 0 and counts everything slower.
+type LatencyBucket struct {
+	Below time.Duration `json:"below"`
+	Count int           `json:"count"`
+}
+
+// histogramBounds are the upper bounds of the latency buckets, spaced for LAN probes at
+// the low end and discovery timeouts at the high end
+var histogramBounds = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+}
+
+// DiscoverServersWithReport scans for game servers like DiscoverServers and also returns
+// a report of the scan
+func DiscoverServersWithReport(ctx context.Context, addr string, opts ...Option) ([]*protocol.ServerInfo, *ScanReport, error) {
+	recorder := &scanRecorder{ports: make(map[int]bool)}
+	opts = append(opts[:len(opts):len(opts)], recorder.attach)
+
+	start := time.Now()
+	servers, err := discoverServers(ctx, addr, opts, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return servers, recorder.report(time.Since(start)), nil
+}
+
+// scanRecorder accumulates scan events from the discovery workers
+type scanRecorder struct {
+	mu        sync.Mutex
+	ports     map[int]bool
+	probes    int
+	histogram []LatencyBucket
+}
+
+// attach is applied after the caller's options, so it sees WithTimingHistogram and
+// chains any WithScanEventCallback callback
+func (r *scanRecorder) attach(o *QueryOptions) {
+	if o.TimingHistogram {
+		r.histogram = make([]LatencyBucket, len(histogramBounds)+1)
+		for i, bound := range histogramBounds {
+			r.histogram[i].Below = bound
+		}
+	}
+	next := o.ScanEvents
+	o.ScanEvents = func(event ScanEvent) {
+		r.record(event)
+		if next != nil {
+			next(event)
+		}
+	}
+}
+
+func (r *scanRecorder) record(event ScanEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ports[event.Port] = true
+	// Skipped protocols report no latency and sent nothing
+	if event.Latency == 0 {
+		return
+	}
+	r.probes++
+	if r.histogram == nil {
+		return
+	}
+	bucket := len(histogramBounds)
+	for i, bound := range histogramBounds {
+		if event.Latency < bound {
+			bucket = i
+			break
+		}
+	}
+	r.histogram[bucket].Count++
+}
+
+func (r *scanRecorder) report(duration time.Duration) *ScanReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &ScanReport{
+		PortsScanned: len(r.ports),
+		Probes:       r.probes,
+		Duration:     duration,
+		Histogram:    r.histogram,
+	}
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverServersWithReport(t *testing.T) {
+	port := startA2SResponder(t, "Reported Server", 440)
+	var events atomic.Int32
+
+	servers, report, err := DiscoverServersWithReport(context.Background(), "127.0.0.1",
+		WithPorts([]int{port, closedPort(t)}),
+		WithTimeout(500*time.Millisecond),
+		WithTimingHistogram(),
+		WithScanEventCallback(func(ScanEvent) { events.Add(1) }))
+
+	assert.NoError(t, err)
+	assert.Len(t, servers, 1)
+	assert.Equal(t, 2, report.PortsScanned)
+	assert.Greater(t, report.Probes, 0)
+	assert.Greater(t, report.Duration, time.Duration(0))
+	// The caller's callback still sees every event, skipped protocols included
+	assert.GreaterOrEqual(t, int(events.Load()), report.Probes)
+
+	total := 0
+	for _, bucket := range report.Histogram {
+		total += bucket.Count
+	}
+	assert.Equal(t, report.Probes, total)
+
+	// Without the option the report has no histogram
+	_, report, err = DiscoverServersWithReport(context.Background(), "127.0.0.1", WithPorts([]int{port}))
+	assert.NoError(t, err)
+	assert.Nil(t, report.Histogram)
+}
+
+func TestScanRecorder_Buckets(t *testing.T) {
+	recorder := &scanRecorder{ports: make(map[int]bool)}
+	recorder.attach(&QueryOptions{TimingHistogram: true})
+
+	for _, latency := range []time.Duration{
+		5 * time.Millisecond,
+		10 * time.Millisecond, // Bounds are exclusive
+		75 * time.Millisecond,
+		3 * time.Second,
+		0, // Skipped
+	} {
+		recorder.record(ScanEvent{Port: 27015, Latency: latency})
+	}
+
+	report := recorder.report(time.Second)
+	assert.Equal(t, 1, report.PortsScanned)
+	assert.Equal(t, 4, report.Probes)
+	assert.Equal(t, LatencyBucket{Below: 10 * time.Millisecond, Count: 1}, report.Histogram[0])
+	assert.Equal(t, LatencyBucket{Below: 25 * time.Millisecond, Count: 1}, report.Histogram[1])
+	assert.Equal(t, LatencyBucket{Below: 100 * time.Millisecond, Count: 1}, report.Histogram[3])
+	assert.Equal(t, LatencyBucket{Count: 1}, report.Histogram[len(report.Histogram)-1])
+}
@@ -0,0 +1,136 @@
+package query
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// maxPlausiblePlayers is the largest max player count not flagged as implausible
+const maxPlausiblePlayers = 10000
+
+// applySanityChecks clamps implausible player counts and records a comma separated
+// list of what was found in info.Extra["sanity_warnings"]
+func applySanityChecks(info *protocol.ServerInfo) {
+	var warnings []string
+
+	if info.Players.Current < 0 {
+		warnings = append(warnings, "negative_current")
+		info.Players.Current = 0
+	}
+	if info.Players.Max < 0 {
+		warnings = append(warnings, "negative_max")
+		info.Players.Max = 0
+	}
+	// Max is only flagged, since clamping it would hide what the server advertised
+	if info.Players.Max > maxPlausiblePlayers {
+		warnings = append(warnings, "max_implausible")
+	}
+	if info.Players.Max > 0 && info.Players.Current > info.Players.Max {
+		warnings = append(warnings, "current_exceeds_max")
+		info.Players.Current = info.Players.Max
+	}
+
+	if len(warnings) == 0 {
+		return
+	}
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["sanity_warnings"] = strings.Join(warnings, ",")
+}
+
+// truncateNames shortens the server name and player names to at most n runes,
+// marking truncated names with a trailing ellipsis
+func truncateNames(info *protocol.ServerInfo, n int) {
+	info.Name = truncateName(info.Name, n)
+	for i := range info.Players.List {
+		info.Players.List[i].Name = truncateName(info.Players.List[i].Name, n)
+	}
+}
+
+// truncateName returns name cut to n runes, the last of which is an ellipsis when cut
+func truncateName(name string, n int) string {
+	runes := []rune(name)
+	if len(runes) <= n {
+		return name
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// limitPlayers cuts the player list to its first n entries, flagging the cut in Extra
+func limitPlayers(info *protocol.ServerInfo, n int) {
+	if len(info.Players.List) <= n {
+		return
+	}
+	info.Players.List = info.Players.List[:n]
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["player_list_truncated"] = "true"
+}
+
+// dedupPlayers removes player list entries whose name already appeared, keeping order
+func dedupPlayers(info *protocol.ServerInfo) {
+	if len(info.Players.List) == 0 {
+		return
+	}
+	seen := make(map[string]bool, len(info.Players.List))
+	players := info.Players.List[:0]
+	for _, player := range info.Players.List {
+		if seen[player.Name] {
+			continue
+		}
+		seen[player.Name] = true
+		players = append(players, player)
+	}
+	info.Players.List = players
+}
+
+// sortScoreboard orders players by team, then by descending score. Players without a team
+// sort after those with one, and ties keep the order the server sent.
+func sortScoreboard(players []protocol.Player) {
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if a.Team != b.Team {
+			if a.Team == "" || b.Team == "" {
+				return b.Team == ""
+			}
+			return a.Team < b.Team
+		}
+		return a.Score > b.Score
+	})
+}
+
+// excludeBots drops bot-flagged players and records the human player count, taking the
+// reported bot count into account since A2S counts bots without flagging them in the list
+func excludeBots(info *protocol.ServerInfo) {
+	flagged := 0
+	players := info.Players.List[:0]
+	for _, player := range info.Players.List {
+		if player.Bot {
+			flagged++
+			continue
+		}
+		players = append(players, player)
+	}
+	if info.Players.List != nil {
+		info.Players.List = players
+	}
+
+	bots := flagged
+	if reported, err := strconv.Atoi(info.Extra["bots"]); err == nil && reported > bots {
+		bots = reported
+	}
+	humans := info.Players.Current - bots
+	if humans < 0 {
+		humans = 0
+	}
+
+	if info.Extra == nil {
+		info.Extra = make(map[string]string)
+	}
+	info.Extra["human_players"] = strconv.Itoa(humans)
+}
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySanityChecks(t *testing.T) {
+	tests := []struct {
+		name         string
+		current, max int
+		wantCurrent  int
+		wantMax      int
+		wantWarnings string
+	}{
+		{"plausible", 10, 20, 10, 20, ""},
+		{"current exceeds max", 200, 64, 64, 64, "current_exceeds_max"},
+		{"max implausible", 5, 65535, 5, 65535, "max_implausible"},
+		{"negative values", -1, -56, 0, 0, "negative_current,negative_max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &protocol.ServerInfo{Players: protocol.PlayerInfo{Current: tt.current, Max: tt.max}}
+
+			applySanityChecks(info)
+
+			assert.Equal(t, tt.wantCurrent, info.Players.Current)
+			assert.Equal(t, tt.wantMax, info.Players.Max)
+			if tt.wantWarnings == "" {
+				assert.Nil(t, info.Extra)
+			} else {
+				assert.Equal(t, tt.wantWarnings, info.Extra["sanity_warnings"])
+			}
+		})
+	}
+}
+
+func TestTruncateNames(t *testing.T) {
+	info := &protocol.ServerInfo{
+		Name: "★★★ Best Server Ever ★★★",
+		Players: protocol.PlayerInfo{List: []protocol.Player{
+			{Name: "Short"},
+			{Name: "ÅÅÅÅÅÅÅÅÅÅÅÅ"},
+		}},
+	}
+
+	truncateNames(info, 8)
+
+	assert.Equal(t, "★★★ Bes…", info.Name)
+	assert.Equal(t, "Short", info.Players.List[0].Name)
+	assert.Equal(t, "ÅÅÅÅÅÅÅ…", info.Players.List[1].Name)
+}
+
+func TestLimitPlayers(t *testing.T) {
+	info := &protocol.ServerInfo{Players: protocol.PlayerInfo{Current: 3, List: []protocol.Player{
+		{Name: "Alice"},
+		{Name: "Bob"},
+		{Name: "Carol"},
+	}}}
+
+	limitPlayers(info, 3)
+	assert.Len(t, info.Players.List, 3)
+	assert.Nil(t, info.Extra)
+
+	limitPlayers(info, 2)
+	assert.Equal(t, []protocol.Player{{Name: "Alice"}, {Name: "Bob"}}, info.Players.List)
+	assert.Equal(t, 3, info.Players.Current)
+	assert.Equal(t, "true", info.Extra["player_list_truncated"])
+}
+
+func TestDedupPlayers(t *testing.T) {
+	info := &protocol.ServerInfo{Players: protocol.PlayerInfo{Current: 4, List: []protocol.Player{
+		{Name: "Alice", Score: 3},
+		{Name: "Bob"},
+		{Name: "Alice", Score: 1},
+		{Name: "alice"},
+	}}}
+
+	dedupPlayers(info)
+
+	assert.Equal(t, []protocol.Player{{Name: "Alice", Score: 3}, {Name: "Bob"}, {Name: "alice"}}, info.Players.List)
+	assert.Equal(t, 4, info.Players.Current, "the reported count is left alone")
+}
+
+func TestSortScoreboard(t *testing.T) {
+	players := []protocol.Player{
+		{Name: "Spectator", Score: 50},
+		{Name: "RedLow", Team: "Red", Score: 1},
+		{Name: "BlueHigh", Team: "Blue", Score: 30},
+		{Name: "RedHigh", Team: "Red", Score: 20},
+		{Name: "BlueTieFirst", Team: "Blue", Score: 5},
+		{Name: "BlueTieSecond", Team: "Blue", Score: 5},
+	}
+
+	sortScoreboard(players)
+
+	var names []string
+	for _, player := range players {
+		names = append(names, player.Name)
+	}
+	assert.Equal(t, []string{"BlueHigh", "BlueTieFirst", "BlueTieSecond", "RedHigh", "RedLow", "Spectator"}, names)
+}
+
+func TestExcludeBots(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        *protocol.ServerInfo
+		wantPlayers []string
+		wantHumans  string
+	}{
+		{
+			name: "flagged bots",
+			info: &protocol.ServerInfo{Players: protocol.PlayerInfo{Current: 3, List: []protocol.Player{
+				{Name: "Alice"}, {Name: "BOT Bob", Bot: true}, {Name: "Carol"},
+			}}},
+			wantPlayers: []string{"Alice", "Carol"},
+			wantHumans:  "2",
+		},
+		{
+			name: "reported bot count",
+			info: &protocol.ServerInfo{
+				Players: protocol.PlayerInfo{Current: 10, List: []protocol.Player{{Name: "Alice"}}},
+				Extra:   map[string]string{"bots": "8"},
+			},
+			wantPlayers: []string{"Alice"},
+			wantHumans:  "2",
+		},
+		{
+			name:       "no list and no bots",
+			info:       &protocol.ServerInfo{Players: protocol.PlayerInfo{Current: 4}},
+			wantHumans: "4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			excludeBots(tt.info)
+
+			var names []string
+			for _, player := range tt.info.Players.List {
+				names = append(names, player.Name)
+			}
+			assert.Equal(t, tt.wantPlayers, names)
+			assert.Equal(t, tt.wantHumans, tt.info.Extra["human_players"])
+		})
+	}
+}
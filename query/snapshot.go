@@ -0,0 +1,124 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// SnapshotVersion is the envelope version written by SaveSnapshot
+const SnapshotVersion = 1
+
+// snapshot is the versioned JSON envelope used to persist scan results
+type snapshot struct {
+	Version int                    `json:"version"`
+	TakenAt time.Time              `json:"taken_at"`
+	Servers []*protocol.ServerInfo `json:"servers"`
+}
+
+// PlayerDelta describes a player count change for a server present in both snapshots
+type PlayerDelta struct {
+	Key    string               `json:"key"`
+	Server *protocol.ServerInfo `json:"server"`
+	Old    int                  `json:"old"`
+	New    int                  `json:"new"`
+	Delta  int                  `json:"delta"`
+}
+
+// SnapshotDiff reports the changes between two snapshots
+type SnapshotDiff struct {
+	Appeared      []*protocol.ServerInfo `json:"appeared"`
+	Disappeared   []*protocol.ServerInfo `json:"disappeared"`
+	PlayerChanges []PlayerDelta          `json:"player_changes"`
+}
+
+// SaveSnapshot writes servers to w as a versioned JSON snapshot
+func SaveSnapshot(w io.Writer, servers []*protocol.ServerInfo) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot{
+		Version: SnapshotVersion,
+		TakenAt: time.Now().UTC(),
+		Servers: servers,
+	})
+}
+
+// LoadSnapshot reads servers from a snapshot written by SaveSnapshot
+func LoadSnapshot(r io.Reader) ([]*protocol.ServerInfo, error) {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+	return snap.Servers, nil
+}
+
+// DiffSnapshots compares two sets of servers keyed by address and query port, reporting
+// servers that appeared or disappeared and player count changes, each sorted by key
+func DiffSnapshots(oldServers, newServers []*protocol.ServerInfo) SnapshotDiff {
+	oldByKey := indexServers(oldServers)
+	newByKey := indexServers(newServers)
+
+	var diff SnapshotDiff
+	for _, key := range sortedKeys(newByKey) {
+		current := newByKey[key]
+		previous, existed := oldByKey[key]
+		if !existed {
+			diff.Appeared = append(diff.Appeared, current)
+			continue
+		}
+		if previous.Players.Current != current.Players.Current {
+			diff.PlayerChanges = append(diff.PlayerChanges, PlayerDelta{
+				Key:    key,
+				Server: current,
+				Old:    previous.Players.Current,
+				New:    current.Players.Current,
+				Delta:  current.Players.Current - previous.Players.Current,
+			})
+		}
+	}
+	for _, key := range sortedKeys(oldByKey) {
+		if _, exists := newByKey[key]; !exists {
+			diff.Disappeared = append(diff.Disappeared, oldByKey[key])
+		}
+	}
+	return diff
+}
+
+// snapshotKey identifies a server by the address and port that answered the query
+func snapshotKey(info *protocol.ServerInfo) string {
+	port := info.QueryPort
+	if port == 0 {
+		port = info.Port
+	}
+	return net.JoinHostPort(info.Address, strconv.Itoa(port))
+}
+
+// indexServers maps online servers by snapshot key
+func indexServers(servers []*protocol.ServerInfo) map[string]*protocol.ServerInfo {
+	index := make(map[string]*protocol.ServerInfo, len(servers))
+	for _, info := range servers {
+		if info != nil && info.Online {
+			index[snapshotKey(info)] = info
+		}
+	}
+	return index
+}
+
+// sortedKeys returns the keys of a server index in sorted order
+func sortedKeys(index map[string]*protocol.ServerInfo) []string {
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshotServer(address string, port, players int) *protocol.ServerInfo {
+	return &protocol.ServerInfo{
+		Name:      "Server",
+		Game:      "minecraft",
+		Address:   address,
+		Port:      port,
+		QueryPort: port,
+		Online:    true,
+		Players:   protocol.PlayerInfo{Current: players, Max: 20},
+	}
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	servers := []*protocol.ServerInfo{
+		snapshotServer("10.0.0.1", 25565, 4),
+		snapshotServer("10.0.0.1", 27015, 12),
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, SaveSnapshot(&buf, servers))
+
+	loaded, err := LoadSnapshot(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, servers, loaded)
+}
+
+func TestSnapshot_RejectsUnknownVersion(t *testing.T) {
+	_, err := LoadSnapshot(strings.NewReader(`{"version": 99, "servers": []}`))
+	assert.ErrorContains(t, err, "unsupported snapshot version")
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	oldServers := []*protocol.ServerInfo{
+		snapshotServer("10.0.0.1", 25565, 4),
+		snapshotServer("10.0.0.1", 27015, 12),
+		snapshotServer("10.0.0.1", 7777, 1),
+	}
+	newServers := []*protocol.ServerInfo{
+		snapshotServer("10.0.0.1", 25565, 9),
+		snapshotServer("10.0.0.1", 27015, 12),
+		snapshotServer("10.0.0.1", 28015, 30),
+	}
+
+	diff := DiffSnapshots(oldServers, newServers)
+
+	assert.Len(t, diff.Appeared, 1)
+	assert.Equal(t, 28015, diff.Appeared[0].Port)
+	assert.Len(t, diff.Disappeared, 1)
+	assert.Equal(t, 7777, diff.Disappeared[0].Port)
+	assert.Len(t, diff.PlayerChanges, 1)
+	assert.Equal(t, PlayerDelta{Key: "10.0.0.1:25565", Server: newServers[0], Old: 4, New: 9, Delta: 5}, diff.PlayerChanges[0])
+}
@@ -0,0 +1,26 @@
+package query
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+)
+
+// stateHash returns an MD5 hex digest of the advertised fields that define a
+// server's visible state: name, map, version and current/max players. Ping,
+// player lists and extras are left out so unchanged servers hash the same each poll.
+func stateHash(info *protocol.ServerInfo) string {
+	fields := []string{
+		info.Name,
+		info.Map,
+		info.Version,
+		strconv.Itoa(info.Players.Current),
+		strconv.Itoa(info.Players.Max),
+	}
+	// Join with NUL so text moving between adjacent fields still changes the hash
+	sum := md5.Sum([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/0xkowalskidev/gameserverquery/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateHash(t *testing.T) {
+	base := &protocol.ServerInfo{Name: "Server", Map: "de_dust2", Version: "1.0", Players: protocol.PlayerInfo{Current: 5, Max: 10}}
+	hash := stateHash(base)
+	assert.Len(t, hash, 32)
+
+	// Fields outside the advertised state don't change the hash
+	noisy := *base
+	noisy.Ping = 42
+	noisy.Extra = map[string]string{"os": "linux"}
+	noisy.Players.List = []protocol.Player{{Name: "Alice"}}
+	assert.Equal(t, hash, stateHash(&noisy))
+
+	// Any advertised field does
+	for _, change := range []func(info *protocol.ServerInfo){
+		func(info *protocol.ServerInfo) { info.Name = "Renamed" },
+		func(info *protocol.ServerInfo) { info.Map = "de_nuke" },
+		func(info *protocol.ServerInfo) { info.Version = "1.1" },
+		func(info *protocol.ServerInfo) { info.Players.Current = 6 },
+		func(info *protocol.ServerInfo) { info.Players.Max = 12 },
+	} {
+		changed := *base
+		change(&changed)
+		assert.NotEqual(t, hash, stateHash(&changed))
+	}
+
+	// Field boundaries are not ambiguous
+	shifted := *base
+	shifted.Name, shifted.Map = "Serverde", "_dust2"
+	assert.NotEqual(t, hash, stateHash(&shifted))
+}
+
+func TestQuery_StateHash(t *testing.T) {
+	port := startA2SResponder(t, "Hashed Server", 440)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	info, err := Query(context.Background(), addr, WithGame("a2s"), WithStateHash())
+	assert.NoError(t, err)
+	assert.Equal(t, stateHash(info), info.Extra["state_hash"])
+
+	info, err = Query(context.Background(), addr, WithGame("a2s"))
+	assert.NoError(t, err)
+	assert.NotContains(t, info.Extra, "state_hash")
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version and Commit identify the build. Release builds set them with
+// -ldflags "-X github.com/0xkowalskidev/gameserverquery/query.Version=v1.2.3 -X .../query.Commit=abc123";
+// left empty, ReadBuildInfo falls back to what the Go toolchain recorded.
+var (
+	Version = ""
+	Commit  = ""
+)
+
+// modulePath is the module this package belongs to, looked up in the build info
+const modulePath = "github.com/0xkowalskidev/gameserverquery"
+
+// BuildInfo describes the running build, for bug reports and version checks
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// ReadBuildInfo returns the library version and commit, preferring the values set with
+// -ldflags. Otherwise the version is the module version the binary was built against,
+// which `go install ...@v1.2.3` and dependents record, and the commit is the VCS
+// revision recorded when building from a checkout. Unknown versions read "(devel)".
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" {
+			info.Version = moduleVersion(bi)
+		}
+		if info.Commit == "" && bi.Main.Path == modulePath {
+			for _, setting := range bi.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	return info
+}
+
+// moduleVersion finds this module's version in bi, as the main module or a dependency
+func moduleVersion(bi *debug.BuildInfo) string {
+	if bi.Main.Path == modulePath {
+		return bi.Main.Version
+	}
+	for _, dep := range bi.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package query
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBuildInfo_LinkerValues(t *testing.T) {
+	savedVersion, savedCommit := Version, Commit
+	Version, Commit = "v1.2.3", "abc123"
+	defer func() { Version, Commit = savedVersion, savedCommit }()
+
+	assert.Equal(t, BuildInfo{Version: "v1.2.3", Commit: "abc123", GoVersion: runtime.Version()}, ReadBuildInfo())
+}
+
+func TestReadBuildInfo_Fallback(t *testing.T) {
+	info := ReadBuildInfo()
+	assert.NotEmpty(t, info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+func TestModuleVersion(t *testing.T) {
+	main := &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v0.3.0"}}
+	assert.Equal(t, "v0.3.0", moduleVersion(main))
+
+	dependent := &debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/panel"},
+		Deps: []*debug.Module{
+			{Path: "github.com/stretchr/testify", Version: "v1.9.0"},
+			{Path: modulePath, Version: "v0.2.1"},
+		},
+	}
+	assert.Equal(t, "v0.2.1", moduleVersion(dependent))
+
+	assert.Equal(t, "", moduleVersion(&debug.BuildInfo{Main: debug.Module{Path: "example.com/other"}}))
+}